@@ -11,12 +11,16 @@ import (
 	"syscall"
 	"time"
 
+	"health-caretaker/internal/alerting"
 	"health-caretaker/internal/config"
 	"health-caretaker/internal/handlers"
+	"health-caretaker/internal/maintenance"
 	"health-caretaker/internal/metrics"
 	"health-caretaker/internal/models"
 	"health-caretaker/internal/monitor"
+	"health-caretaker/internal/notify"
 	"health-caretaker/internal/server"
+	"health-caretaker/internal/storage"
 	"health-caretaker/pkg/logger"
 	"health-caretaker/pkg/middleware"
 
@@ -33,7 +37,8 @@ var (
 func main() {
 	// Parse command line flags
 	var (
-		configFile  = flag.String("config", "config.json", "Configuration file path")
+		configFile  = flag.String("config", "config.json", "Configuration file, directory or glob pattern (JSON or YAML)")
+		watchConfig = flag.Bool("watch-config", true, "Reload the configuration when its files change")
 		showVersion = flag.Bool("version", false, "Show version information")
 	)
 	flag.Parse()
@@ -58,24 +63,90 @@ func main() {
 	log.Info("Loaded configuration from %s", *configFile)
 	log.Info("Found %d endpoints in configuration", len(cfg.Endpoints))
 
+	// Open the persistent store, if configured
+	store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.Path)
+	if err != nil {
+		log.Fatal("Failed to open storage: %v", err)
+	}
+	if store != nil {
+		defer store.Close()
+		log.Info("Persisting state to %s (%s backend)", cfg.Storage.Path, cfg.Storage.Backend)
+	}
+
 	// Create monitor instance
 	monitor := monitor.NewMonitor()
+	monitor.SetPoolConfig(poolConfig(cfg))
+	monitor.SetHistorySize(cfg.History.Size)
+	if store != nil {
+		monitor.SetStore(store, time.Duration(cfg.Storage.RetentionDays)*24*time.Hour)
+	}
+
+	// Load endpoints from configuration, merged with the stored state
+	configured := make([]*models.Endpoint, 0, len(cfg.Endpoints))
+	for _, endpointConfig := range cfg.Endpoints {
+		configured = append(configured, endpointConfig.ToEndpoint())
+	}
+	// Restore runs before silences and maintenance windows are loaded, as it
+	// may move stored state to new endpoint IDs
+	endpoints, err := monitor.Restore(configured)
+	if err != nil {
+		log.Fatal("Failed to restore stored state: %v", err)
+	}
+
+	// Maintenance windows from the configuration and the API
+	windows, err := maintenance.New(cfg.Maintenance, store)
+	if err != nil {
+		log.Fatal("Failed to load maintenance windows: %v", err)
+	}
+	monitor.SetMaintenance(windows)
 
 	// Create metrics collector
 	metricsCollector := metrics.NewMetricsCollector()
+	metricsCollector.SetPoolStatsFunc(monitor.PoolStats)
 
 	// Set up metrics callback
 	monitor.SetMetricsCallback(func(endpoint *models.Endpoint) {
 		metricsCollector.UpdateEndpoint(endpoint)
 	})
 
+	// Set up notifications for status transitions
+	deadLetter, err := notify.NewDeadLetterLog(cfg.Notifications.DeadLetterFile)
+	if err != nil {
+		log.Fatal("Failed to open dead-letter log: %v", err)
+	}
+	defer deadLetter.Close()
+
+	dispatcher, err := notify.NewDispatcher(cfg.Notifications.Notifiers, deadLetter)
+	if err != nil {
+		log.Fatal("Failed to create notifiers: %v", err)
+	}
+	for _, notifier := range cfg.Notifications.Notifiers {
+		log.Info("Notifier enabled: %s (%s)", notifier.Name, notifier.Type)
+	}
+
+	// Route transitions through silences, groups and reminders
+	silences, err := alerting.NewSilences(store)
+	if err != nil {
+		log.Fatal("Failed to load silences: %v", err)
+	}
+	route := cfg.Notifications.Route
+	if route == nil {
+		route = alerting.DefaultRoute(dispatcher.Receivers())
+	}
+	router := alerting.NewRouter(route, dispatcher, silences, windows, monitor.IsAcknowledged)
+	monitor.SetTransitionCallback(router.Handle)
+
 	// Create handler instance
 	handler := handlers.NewHandler(monitor, metricsCollector)
-
-	// Load endpoints from configuration
-	for _, endpointConfig := range cfg.Endpoints {
-		endpoint := endpointConfig.ToEndpoint()
-		monitor.AddEndpoint(endpoint)
+	handler.SetWebSocketHistory(cfg.History.WebSocketResults)
+	handler.SetSilences(silences)
+	handler.SetMaintenance(windows)
+
+	for _, endpoint := range endpoints {
+		if err := monitor.AddEndpoint(endpoint); err != nil {
+			log.Error("Failed to add endpoint %s (%s): %v", endpoint.Name, endpoint.ID, err)
+			continue
+		}
 		log.Info("Added endpoint: %s (%s)", endpoint.Name, endpoint.URL)
 		if endpoint.Labels != nil && len(endpoint.Labels) > 0 {
 			log.Info("  Labels: %v", endpoint.Labels)
@@ -89,6 +160,36 @@ func main() {
 	defer cancel()
 	go monitor.StartMonitoring(ctx)
 
+	// Reload the configuration on SIGHUP and when the file changes
+	reloader := &reloader{
+		path:     *configFile,
+		current:  cfg,
+		monitor:  monitor,
+		windows:  windows,
+		silences: silences,
+		metrics:  metricsCollector,
+		log:      log,
+	}
+	handler.SetConfigSource(func() (interface{}, error) {
+		return reloader.config().Effective()
+	})
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			reloader.reload("SIGHUP")
+		}
+	}()
+	if *watchConfig {
+		watcher, err := config.NewWatcher(*configFile)
+		if err != nil {
+			log.Error("Config file watching disabled: %v", err)
+		} else {
+			go watcher.Run(ctx, func() { reloader.reload("file changed") })
+			log.Info("Watching %s for changes", *configFile)
+		}
+	}
+
 	// Setup main server routes
 	mainRouter := mux.NewRouter()
 
@@ -108,6 +209,17 @@ func main() {
 	api.HandleFunc("/endpoints", handler.HandleAPIEndpoints).Methods("GET", "POST")
 	api.HandleFunc("/endpoints/{id}", handler.HandleAPIEndpoints).Methods("DELETE")
 	api.HandleFunc("/endpoints/{id}/check", handler.HandleCheckEndpoint).Methods("POST")
+	api.HandleFunc("/endpoints/{id}/history", handler.HandleEndpointHistory).Methods("GET")
+	api.HandleFunc("/endpoints/{id}/ack", handler.HandleAcknowledge).Methods("POST")
+	api.HandleFunc("/reports/uptime", handler.HandleUptimeReport).Methods("GET")
+	api.HandleFunc("/incidents", handler.HandleIncidents).Methods("GET")
+	api.HandleFunc("/incidents/{id}", handler.HandleIncident).Methods("GET")
+	api.HandleFunc("/incidents/{id}/notes", handler.HandleIncidentNote).Methods("POST")
+	api.HandleFunc("/maintenance", handler.HandleMaintenance).Methods("GET", "POST")
+	api.HandleFunc("/maintenance/{id}", handler.HandleMaintenance).Methods("DELETE")
+	api.HandleFunc("/silences", handler.HandleSilences).Methods("GET", "POST")
+	api.HandleFunc("/silences/{id}", handler.HandleSilences).Methods("DELETE")
+	api.HandleFunc("/config/effective", handler.HandleEffectiveConfig).Methods("GET")
 
 	// WebSocket
 	mainRouter.HandleFunc("/ws", handler.HandleWebSocket)
@@ -173,5 +285,9 @@ func main() {
 		log.Error("Failed to stop main server: %v", err)
 	}
 
+	// Flush pending notifications
+	router.Close()
+	dispatcher.Close(shutdownCtx)
+
 	log.Info("Health monitoring service stopped")
 }
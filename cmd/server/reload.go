@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+
+	"health-caretaker/internal/alerting"
+	"health-caretaker/internal/config"
+	"health-caretaker/internal/maintenance"
+	"health-caretaker/internal/metrics"
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/monitor"
+	"health-caretaker/pkg/logger"
+)
+
+// reloader applies a changed configuration file to the running service
+type reloader struct {
+	path     string
+	current  *config.Config
+	monitor  *monitor.Monitor
+	windows  *maintenance.Windows
+	silences *alerting.Silences
+	metrics  *metrics.MetricsCollector
+	log      *logger.Logger
+	mutex    sync.Mutex
+}
+
+// reload loads the configuration file again and applies the endpoints,
+// maintenance windows and probe concurrency limits. An invalid configuration
+// is rejected and the running one is kept.
+func (r *reloader) reload(trigger string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.log.Info("Reloading configuration from %s (%s)", r.path, trigger)
+	// LoadConfig writes a default configuration for a missing file, which
+	// must not replace the running one
+	_, err := config.Files(r.path)
+	var cfg *config.Config
+	if err == nil {
+		cfg, err = config.LoadConfig(r.path)
+	}
+	if err == nil {
+		err = r.windows.SetConfigured(cfg.Maintenance)
+	}
+	if err != nil {
+		r.log.Error("Configuration reload failed, keeping the running configuration: %v", err)
+		r.metrics.RecordReload(false)
+		return
+	}
+
+	r.monitor.SetPoolConfig(poolConfig(cfg))
+
+	configured := make([]*models.Endpoint, 0, len(cfg.Endpoints))
+	for _, endpointConfig := range cfg.Endpoints {
+		configured = append(configured, endpointConfig.ToEndpoint())
+	}
+	result := r.monitor.ApplyConfig(configured)
+	for _, endpoint := range result.Added {
+		r.log.Info("Added endpoint: %s (%s)", endpoint.Name, endpoint.URL)
+	}
+	for _, endpoint := range result.Updated {
+		r.log.Info("Updated endpoint: %s (%s)", endpoint.Name, endpoint.URL)
+	}
+	for oldID, newID := range result.Renamed {
+		r.windows.RenameEndpoint(oldID, newID)
+		r.silences.RenameEndpoint(oldID, newID)
+		r.metrics.RemoveEndpoint(oldID)
+		r.log.Info("Endpoint %s moved to ID %s", oldID, newID)
+	}
+	for _, endpoint := range result.Removed {
+		r.metrics.RemoveEndpoint(endpoint.ID)
+		r.log.Info("Removed endpoint: %s (%s)", endpoint.Name, endpoint.URL)
+	}
+
+	for _, section := range restartRequired(r.current, cfg) {
+		r.log.Info("Changes to %s take effect after a restart", section)
+	}
+
+	r.current = cfg
+	r.metrics.RecordReload(true)
+	r.log.Info("Configuration reloaded: %d added, %d updated, %d removed, %d unchanged",
+		len(result.Added), len(result.Updated), len(result.Removed), result.Unchanged)
+}
+
+// config returns the configuration currently in effect
+func (r *reloader) config() *config.Config {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.current
+}
+
+// poolConfig returns the probe concurrency limits of a configuration
+func poolConfig(cfg *config.Config) monitor.PoolConfig {
+	return monitor.PoolConfig{
+		MaxConcurrent: cfg.Concurrency.MaxConcurrent,
+		PerHost:       cfg.Concurrency.PerHost,
+		GroupLabel:    cfg.Concurrency.GroupLabel,
+		PerGroup:      cfg.Concurrency.PerGroup,
+		GroupLimits:   cfg.Concurrency.GroupLimits,
+	}
+}
+
+// restartRequired returns the configuration sections that changed but are
+// only read at startup
+func restartRequired(old, updated *config.Config) []string {
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"server", old.Server, updated.Server},
+		{"metrics", old.Metrics, updated.Metrics},
+		{"history", old.History, updated.History},
+		{"storage", old.Storage, updated.Storage},
+		{"notifications", old.Notifications, updated.Notifications},
+	}
+
+	var changed []string
+	for _, section := range sections {
+		a, _ := json.Marshal(section.old)
+		b, _ := json.Marshal(section.new)
+		if string(a) != string(b) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
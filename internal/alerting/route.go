@@ -0,0 +1,107 @@
+// Package alerting routes status transitions to notifiers, grouping them,
+// applying silences and repeating reminders for unacknowledged outages
+package alerting
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Route is a node of the routing tree. A transition is handled by the deepest
+// matching routes: children are tried in order and the first match wins
+// unless it sets continue. Unset settings are inherited from the parent.
+type Route struct {
+	Receivers      []string          `json:"receivers,omitempty"`       // Notifier names
+	Match          map[string]string `json:"match,omitempty"`           // Endpoint labels this route requires
+	GroupBy        []string          `json:"group_by,omitempty"`        // Labels whose values form a notification group
+	GroupWait      *float64          `json:"group_wait,omitempty"`      // Seconds to collect a group before notifying
+	RepeatInterval *float64          `json:"repeat_interval,omitempty"` // Seconds between reminders while down; 0 disables
+	Continue       bool              `json:"continue,omitempty"`        // Keep matching later siblings after this one
+	Routes         []*Route          `json:"routes,omitempty"`
+}
+
+// DefaultRoute sends every transition to all receivers immediately, without reminders
+func DefaultRoute(receivers []string) *Route {
+	return &Route{Receivers: receivers}
+}
+
+// Validate checks the tree; every receiver must be a known notifier name
+func (r *Route) Validate(receivers map[string]bool) error {
+	return r.validate("route", receivers)
+}
+
+func (r *Route) validate(path string, receivers map[string]bool) error {
+	for _, receiver := range r.Receivers {
+		if !receivers[receiver] {
+			return fmt.Errorf("%s: unknown receiver %q", path, receiver)
+		}
+	}
+	if r.GroupWait != nil && *r.GroupWait < 0 {
+		return fmt.Errorf("%s: group_wait must not be negative", path)
+	}
+	if r.RepeatInterval != nil && *r.RepeatInterval < 0 {
+		return fmt.Errorf("%s: repeat_interval must not be negative", path)
+	}
+	for i, child := range r.Routes {
+		if err := child.validate(path+".routes["+strconv.Itoa(i)+"]", receivers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchedRoute is a route that handles a transition, with inherited settings applied
+type matchedRoute struct {
+	path           string
+	receivers      []string
+	groupBy        []string
+	groupWait      time.Duration
+	repeatInterval time.Duration
+}
+
+// resolve returns the routes that handle an endpoint with the given labels
+func (r *Route) resolve(labels map[string]string) []matchedRoute {
+	return r.match("0", matchedRoute{}, labels)
+}
+
+func (r *Route) match(path string, parent matchedRoute, labels map[string]string) []matchedRoute {
+	for name, value := range r.Match {
+		if labels[name] != value {
+			return nil
+		}
+	}
+
+	current := parent
+	current.path = path
+	if r.Receivers != nil {
+		current.receivers = r.Receivers
+	}
+	if r.GroupBy != nil {
+		current.groupBy = r.GroupBy
+	}
+	if r.GroupWait != nil {
+		current.groupWait = seconds(*r.GroupWait)
+	}
+	if r.RepeatInterval != nil {
+		current.repeatInterval = seconds(*r.RepeatInterval)
+	}
+
+	var matched []matchedRoute
+	for i, child := range r.Routes {
+		routes := child.match(path+"."+strconv.Itoa(i), current, labels)
+		matched = append(matched, routes...)
+		if len(routes) > 0 && !child.Continue {
+			break
+		}
+	}
+	if len(matched) == 0 {
+		matched = []matchedRoute{current}
+	}
+	return matched
+}
+
+// seconds converts fractional seconds to a duration
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,218 @@
+package alerting
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/maintenance"
+	"health-caretaker/internal/models"
+)
+
+// Dispatcher delivers grouped transitions to a named notifier
+type Dispatcher interface {
+	DispatchTo(receiver string, transitions []models.Transition)
+}
+
+// Router sends transitions through the routing tree to the dispatcher
+type Router struct {
+	root        *Route
+	dispatcher  Dispatcher
+	silences    *Silences
+	maintenance *maintenance.Windows
+	acked       func(endpointID string) bool
+
+	mutex     sync.Mutex
+	groups    map[string]*group    // Groups waiting for group_wait, by route and label values
+	reminders map[string]*reminder // Pending reminders, by endpoint and route
+	notified  map[string]bool      // Outages whose notification was sent, by endpoint and route
+	closed    bool
+}
+
+// group collects transitions for the same route and group_by label values
+type group struct {
+	route       matchedRoute
+	transitions []models.Transition
+	timer       *time.Timer
+}
+
+// reminder repeats the down notification of an endpoint for one route
+type reminder struct {
+	route      matchedRoute
+	transition models.Transition
+	timer      *time.Timer
+}
+
+// NewRouter creates a router. Transitions of silenced endpoints and of
+// endpoints in maintenance are dropped, except recoveries from outages that
+// were notified. acked reports whether an endpoint's
+// outage was acknowledged, which stops its reminders.
+func NewRouter(root *Route, dispatcher Dispatcher, silences *Silences, windows *maintenance.Windows, acked func(endpointID string) bool) *Router {
+	return &Router{
+		root:        root,
+		dispatcher:  dispatcher,
+		silences:    silences,
+		maintenance: windows,
+		acked:       acked,
+		groups:      make(map[string]*group),
+		reminders:   make(map[string]*reminder),
+		notified:    make(map[string]bool),
+	}
+}
+
+// Handle routes a transition; it never blocks on delivery
+func (r *Router) Handle(transition models.Transition) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	for _, route := range r.root.resolve(transition.Labels) {
+		if transition.Acknowledged {
+			r.acknowledge(route, transition)
+			continue
+		}
+		r.scheduleReminder(route, transition)
+
+		if route.groupWait <= 0 {
+			r.send(route, []models.Transition{transition})
+			continue
+		}
+
+		key := groupKey(route, transition.Labels)
+		if g, pending := r.groups[key]; pending {
+			g.transitions = append(g.transitions, transition)
+			continue
+		}
+		g := &group{route: route, transitions: []models.Transition{transition}}
+		g.timer = time.AfterFunc(route.groupWait, func() { r.flush(key) })
+		r.groups[key] = g
+	}
+}
+
+// acknowledge passes the acknowledgement of an outage to the route's
+// receivers right away, if they were notified of the outage. Callers hold the
+// mutex.
+func (r *Router) acknowledge(route matchedRoute, transition models.Transition) {
+	if !r.notified[transition.EndpointID+"|"+route.path] {
+		return
+	}
+	for _, receiver := range route.receivers {
+		r.dispatcher.DispatchTo(receiver, []models.Transition{transition})
+	}
+}
+
+// scheduleReminder starts repeating the notification of an outage, or stops
+// it once the endpoint recovers. Callers hold the mutex.
+func (r *Router) scheduleReminder(route matchedRoute, transition models.Transition) {
+	key := transition.EndpointID + "|" + route.path
+	if existing, ok := r.reminders[key]; ok {
+		existing.timer.Stop()
+		delete(r.reminders, key)
+	}
+	if transition.IsRecovery() || route.repeatInterval <= 0 {
+		return
+	}
+
+	rem := &reminder{route: route, transition: transition}
+	rem.timer = time.AfterFunc(route.repeatInterval, func() { r.remind(key, rem) })
+	r.reminders[key] = rem
+}
+
+// remind re-sends an outage notification unless it was acknowledged
+func (r *Router) remind(key string, rem *reminder) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed || r.reminders[key] != rem {
+		return
+	}
+	if r.acked != nil && r.acked(rem.transition.EndpointID) {
+		delete(r.reminders, key)
+		return
+	}
+
+	transition := rem.transition
+	transition.Reminder = true
+	transition.Time = time.Now()
+	r.send(rem.route, []models.Transition{transition})
+	rem.timer = time.AfterFunc(rem.route.repeatInterval, func() { r.remind(key, rem) })
+}
+
+// flush sends a group once its group_wait has passed
+func (r *Router) flush(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	g, pending := r.groups[key]
+	if !pending || r.closed {
+		return
+	}
+	delete(r.groups, key)
+	r.send(g.route, g.transitions)
+}
+
+// send hands the transitions that are not silenced or in maintenance to the
+// route's receivers. The recovery from an outage that was notified is always
+// sent, so that receivers resolve the alerts and incidents they opened.
+// Callers hold the mutex.
+func (r *Router) send(route matchedRoute, transitions []models.Transition) {
+	var active []models.Transition
+	for _, transition := range transitions {
+		key := transition.EndpointID + "|" + route.path
+		if transition.IsRecovery() && r.notified[key] {
+			delete(r.notified, key)
+			active = append(active, transition)
+			continue
+		}
+
+		if r.silences != nil && r.silences.Silenced(transition.EndpointID, transition.Labels) {
+			log.Printf("Notification for %s (%s -> %s) silenced", transition.EndpointName, transition.From, transition.To)
+			continue
+		}
+		if window := r.maintenance.Active(transition.EndpointID, transition.Labels, time.Now()); window != nil {
+			log.Printf("Notification for %s (%s -> %s) suppressed by maintenance window %s", transition.EndpointName, transition.From, transition.To, window.ID)
+			continue
+		}
+		if !transition.IsRecovery() {
+			r.notified[key] = true
+		}
+		active = append(active, transition)
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	for _, receiver := range route.receivers {
+		r.dispatcher.DispatchTo(receiver, active)
+	}
+}
+
+// Close sends all waiting groups immediately and stops reminders
+func (r *Router) Close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for key, g := range r.groups {
+		g.timer.Stop()
+		delete(r.groups, key)
+		r.send(g.route, g.transitions)
+	}
+	for key, rem := range r.reminders {
+		rem.timer.Stop()
+		delete(r.reminders, key)
+	}
+	r.closed = true
+}
+
+// groupKey identifies the group of a transition within a route
+func groupKey(route matchedRoute, labels map[string]string) string {
+	parts := []string{route.path}
+	for _, name := range route.groupBy {
+		parts = append(parts, name+"="+labels[name])
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,270 @@
+package alerting
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// delivery is a group of transitions handed to a receiver
+type delivery struct {
+	receiver    string
+	transitions []models.Transition
+}
+
+// recorder is a Dispatcher that records the deliveries it gets
+type recorder struct {
+	mutex      sync.Mutex
+	deliveries []delivery
+}
+
+func (r *recorder) DispatchTo(receiver string, transitions []models.Transition) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.deliveries = append(r.deliveries, delivery{receiver: receiver, transitions: transitions})
+}
+
+// received returns the deliveries so far
+func (r *recorder) received() []delivery {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]delivery(nil), r.deliveries...)
+}
+
+// wait returns the deliveries once there are at least n of them
+func (r *recorder) wait(t *testing.T, n int) []delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries := r.received()
+		if len(deliveries) >= n {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d deliveries, want %d", len(deliveries), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// secondsOf returns a pointer to a number of seconds for route settings
+func secondsOf(value float64) *float64 {
+	return &value
+}
+
+// transition is the endpoint with the given team label going to status to
+func transition(id, team, to string) models.Transition {
+	from := "up"
+	if to == "up" {
+		from = "down"
+	}
+	return models.Transition{
+		EndpointID:   id,
+		EndpointName: id,
+		Labels:       map[string]string{"team": team},
+		From:         from,
+		To:           to,
+		Time:         time.Now(),
+	}
+}
+
+func TestRouterGroupWait(t *testing.T) {
+	r := &recorder{}
+	router := NewRouter(&Route{
+		Receivers: []string{"ops"},
+		GroupBy:   []string{"team"},
+		GroupWait: secondsOf(0.05),
+	}, r, nil, nil, nil)
+	defer router.Close()
+
+	router.Handle(transition("orders", "shop", "down"))
+	router.Handle(transition("cart", "shop", "down"))
+	router.Handle(transition("search", "platform", "down"))
+	if got := len(r.received()); got != 0 {
+		t.Fatalf("%d deliveries before group_wait passed, want 0", got)
+	}
+
+	deliveries := r.wait(t, 2)
+	time.Sleep(50 * time.Millisecond)
+	if len(r.received()) != 2 {
+		t.Fatalf("deliveries = %+v, want one per team", r.received())
+	}
+	sizes := map[string]int{}
+	for _, d := range deliveries {
+		if d.receiver != "ops" {
+			t.Errorf("delivered to %q, want ops", d.receiver)
+		}
+		sizes[d.transitions[0].Labels["team"]] = len(d.transitions)
+	}
+	if sizes["shop"] != 2 || sizes["platform"] != 1 {
+		t.Errorf("group sizes = %v, want shop 2 and platform 1", sizes)
+	}
+}
+
+func TestRouterReminders(t *testing.T) {
+	tests := []struct {
+		name      string
+		acked     bool
+		reminders bool
+	}{
+		{name: "repeated while down", reminders: true},
+		{name: "stopped by an acknowledgement", acked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			router := NewRouter(&Route{
+				Receivers:      []string{"ops"},
+				RepeatInterval: secondsOf(0.05),
+			}, r, nil, nil, func(string) bool { return tt.acked })
+			defer router.Close()
+
+			router.Handle(transition("orders", "shop", "down"))
+			if !tt.reminders {
+				time.Sleep(200 * time.Millisecond)
+				if deliveries := r.received(); len(deliveries) != 1 {
+					t.Fatalf("%d deliveries for an acknowledged outage, want only the first", len(deliveries))
+				}
+				return
+			}
+
+			deliveries := r.wait(t, 3)
+			for i, d := range deliveries[:3] {
+				if reminder := d.transitions[0].Reminder; reminder != (i > 0) {
+					t.Errorf("delivery %d reminder = %v, want %v", i, reminder, i > 0)
+				}
+			}
+
+			// The recovery is delivered and ends the reminders
+			router.Handle(transition("orders", "shop", "up"))
+			deliveries = r.received()
+			recovered := len(deliveries)
+			if last := deliveries[recovered-1].transitions[0]; !last.IsRecovery() {
+				t.Errorf("last delivery = %+v, want the recovery", last)
+			}
+			time.Sleep(200 * time.Millisecond)
+			if got := len(r.received()); got != recovered {
+				t.Errorf("%d reminders after the recovery, want 0", got-recovered)
+			}
+		})
+	}
+}
+
+func TestRouterSilences(t *testing.T) {
+	tests := []struct {
+		name     string
+		silence  models.Silence
+		silenced map[string]bool // By endpoint ID
+	}{
+		{
+			name:     "by endpoint",
+			silence:  models.Silence{EndpointID: "orders"},
+			silenced: map[string]bool{"orders": true},
+		},
+		{
+			name:     "by label",
+			silence:  models.Silence{Matchers: map[string]string{"team": "shop"}},
+			silenced: map[string]bool{"orders": true, "cart": true},
+		},
+		{
+			name:     "by endpoint and label",
+			silence:  models.Silence{EndpointID: "orders", Matchers: map[string]string{"team": "platform"}},
+			silenced: map[string]bool{},
+		},
+		{
+			name:     "not yet started",
+			silence:  models.Silence{Matchers: map[string]string{"team": "shop"}, StartsAt: time.Now().Add(time.Hour)},
+			silenced: map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			silences, err := NewSilences(nil)
+			if err != nil {
+				t.Fatalf("NewSilences: %v", err)
+			}
+			silence := tt.silence
+			silence.EndsAt = time.Now().Add(2 * time.Hour)
+			if _, err := silences.Add(silence); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+
+			r := &recorder{}
+			router := NewRouter(&Route{Receivers: []string{"ops"}}, r, silences, nil, nil)
+			defer router.Close()
+
+			for _, id := range []string{"orders", "cart", "search"} {
+				team := "shop"
+				if id == "search" {
+					team = "platform"
+				}
+				router.Handle(transition(id, team, "down"))
+			}
+			delivered := map[string]bool{}
+			for _, d := range r.received() {
+				delivered[d.transitions[0].EndpointID] = true
+			}
+			for _, id := range []string{"orders", "cart", "search"} {
+				if delivered[id] == tt.silenced[id] {
+					t.Errorf("%s delivered = %v, want silenced %v", id, delivered[id], tt.silenced[id])
+				}
+			}
+		})
+	}
+}
+
+func TestRouterSilencedRecovery(t *testing.T) {
+	silences, err := NewSilences(nil)
+	if err != nil {
+		t.Fatalf("NewSilences: %v", err)
+	}
+	r := &recorder{}
+	router := NewRouter(&Route{Receivers: []string{"ops"}}, r, silences, nil, nil)
+	defer router.Close()
+
+	// An outage notified before the silence still has its recovery
+	// delivered, so that receivers resolve it
+	router.Handle(transition("orders", "shop", "down"))
+	if _, err := silences.Add(models.Silence{EndpointID: "orders", EndsAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	router.Handle(transition("orders", "shop", "up"))
+	router.Handle(transition("orders", "shop", "down"))
+	router.Handle(transition("orders", "shop", "up"))
+
+	deliveries := r.received()
+	if len(deliveries) != 2 || deliveries[0].transitions[0].IsRecovery() || !deliveries[1].transitions[0].IsRecovery() {
+		t.Errorf("deliveries = %+v, want the first outage and its recovery only", deliveries)
+	}
+}
+
+func TestRouterClose(t *testing.T) {
+	r := &recorder{}
+	router := NewRouter(&Route{
+		Receivers:      []string{"ops"},
+		GroupWait:      secondsOf(3600),
+		RepeatInterval: secondsOf(0.05),
+	}, r, nil, nil, nil)
+
+	router.Handle(transition("orders", "shop", "down"))
+	if got := len(r.received()); got != 0 {
+		t.Fatalf("%d deliveries before group_wait passed, want 0", got)
+	}
+
+	// Close delivers the waiting group right away
+	router.Close()
+	if deliveries := r.received(); len(deliveries) != 1 || len(deliveries[0].transitions) != 1 {
+		t.Fatalf("deliveries after Close = %+v, want the waiting group", deliveries)
+	}
+
+	// Nothing is sent after Close: no reminders and no new transitions
+	router.Handle(transition("cart", "shop", "down"))
+	time.Sleep(200 * time.Millisecond)
+	if got := len(r.received()); got != 1 {
+		t.Errorf("%d deliveries after Close, want 0", got-1)
+	}
+}
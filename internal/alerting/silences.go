@@ -0,0 +1,136 @@
+package alerting
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/storage"
+)
+
+// Silences holds the silences created through the API, persisting them when
+// a store is configured
+type Silences struct {
+	mutex sync.RWMutex
+	items map[string]*models.Silence
+	store storage.Store
+}
+
+// NewSilences creates the silence registry, loading stored silences
+func NewSilences(store storage.Store) (*Silences, error) {
+	s := &Silences{items: make(map[string]*models.Silence), store: store}
+	if store == nil {
+		return s, nil
+	}
+
+	silences, err := store.Silences()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load silences: %v", err)
+	}
+	for _, silence := range silences {
+		s.items[silence.ID] = silence
+	}
+	return s, nil
+}
+
+// Add validates and stores a new silence. A missing start means now.
+func (s *Silences) Add(silence models.Silence) (*models.Silence, error) {
+	now := time.Now()
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if err := silence.Validate(); err != nil {
+		return nil, err
+	}
+	if !silence.EndsAt.After(now) {
+		return nil, fmt.Errorf("silence must end in the future")
+	}
+	silence.ID = fmt.Sprintf("silence_%d", now.UnixNano())
+	silence.CreatedAt = now
+
+	s.mutex.Lock()
+	s.items[silence.ID] = &silence
+	s.mutex.Unlock()
+
+	s.persist(&silence)
+	return &silence, nil
+}
+
+// Expire ends a silence now. It returns false if the silence does not exist.
+func (s *Silences) Expire(id string) (*models.Silence, bool) {
+	s.mutex.Lock()
+	silence, exists := s.items[id]
+	if !exists {
+		s.mutex.Unlock()
+		return nil, false
+	}
+	if now := time.Now(); silence.EndsAt.After(now) {
+		silence.EndsAt = now
+		if silence.StartsAt.After(now) {
+			silence.StartsAt = now
+		}
+	}
+	expired := *silence
+	s.mutex.Unlock()
+
+	s.persist(&expired)
+	return &expired, true
+}
+
+// List returns the silences ordered by start time. Expired silences are only
+// included when requested.
+func (s *Silences) List(includeExpired bool) []*models.Silence {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	silences := make([]*models.Silence, 0, len(s.items))
+	for _, silence := range s.items {
+		if includeExpired || silence.EndsAt.After(now) {
+			copied := *silence
+			silences = append(silences, &copied)
+		}
+	}
+	sort.Slice(silences, func(i, j int) bool { return silences[i].StartsAt.Before(silences[j].StartsAt) })
+	return silences
+}
+
+// Silenced reports whether an active silence matches the endpoint
+func (s *Silences) Silenced(endpointID string, labels map[string]string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	for _, silence := range s.items {
+		if silence.IsActive(now) && silence.Matches(endpointID, labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenameEndpoint points the silences of an endpoint at its new ID. The store
+// is updated together with the endpoint's other records.
+func (s *Silences) RenameEndpoint(oldID, newID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, silence := range s.items {
+		if silence.EndpointID == oldID {
+			silence.EndpointID = newID
+		}
+	}
+}
+
+// persist saves a silence if a store is configured
+func (s *Silences) persist(silence *models.Silence) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.SaveSilence(silence); err != nil {
+		log.Printf("Error saving silence %s: %v", silence.ID, err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package assertions evaluates the configured checks against an HTTP response
+package assertions
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultMaxBodyBytes bounds how much of a body is read when no max_body_bytes is set
+const DefaultMaxBodyBytes = 1 << 20
+
+// Validate checks that every expression in the assertions parses
+func Validate(a *models.HTTPAssertions) error {
+	if a == nil {
+		return nil
+	}
+
+	for _, spec := range a.StatusCodes {
+		if _, _, err := parseStatusSpec(spec); err != nil {
+			return err
+		}
+	}
+
+	if a.BodyRegex != "" {
+		if _, err := regexp.Compile(a.BodyRegex); err != nil {
+			return fmt.Errorf("invalid body_regex: %v", err)
+		}
+	}
+
+	for _, jp := range a.JSONPath {
+		if jp.Path == "" {
+			return fmt.Errorf("json_path entries require a path")
+		}
+		if _, err := parseJSONPath(jp.Path); err != nil {
+			return err
+		}
+	}
+
+	for name, pattern := range a.Headers {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern for header %s: %v", name, err)
+		}
+	}
+
+	if a.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes must not be negative")
+	}
+
+	return nil
+}
+
+// NeedsBody reports whether the response body has to be read
+func NeedsBody(a *models.HTTPAssertions) bool {
+	return a != nil && (a.BodyContains != "" || a.BodyRegex != "" || len(a.JSONPath) > 0 || a.MaxBodyBytes > 0)
+}
+
+// BodyLimit returns the maximum number of body bytes to read
+func BodyLimit(a *models.HTTPAssertions) int64 {
+	if a != nil && a.MaxBodyBytes > 0 {
+		return a.MaxBodyBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// Evaluate runs every assertion against the response. bodyTooLarge is set when
+// the body exceeded BodyLimit and body holds only the first BodyLimit bytes.
+func Evaluate(a *models.HTTPAssertions, statusCode int, header http.Header, body []byte, bodyTooLarge bool) []models.AssertionResult {
+	results := []models.AssertionResult{checkStatus(a, statusCode)}
+	if a == nil {
+		return results
+	}
+
+	if a.MaxBodyBytes > 0 {
+		result := models.AssertionResult{Name: "max_body_bytes", Passed: !bodyTooLarge}
+		if bodyTooLarge {
+			result.Message = fmt.Sprintf("body larger than %d bytes", a.MaxBodyBytes)
+		}
+		results = append(results, result)
+	}
+
+	if a.BodyContains != "" {
+		result := models.AssertionResult{Name: "body_contains", Passed: strings.Contains(string(body), a.BodyContains)}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("body does not contain %q", a.BodyContains)
+		}
+		results = append(results, result)
+	}
+
+	if a.BodyRegex != "" {
+		result := models.AssertionResult{Name: "body_regex"}
+		re, err := regexp.Compile(a.BodyRegex)
+		if err != nil {
+			result.Message = err.Error()
+		} else if result.Passed = re.Match(body); !result.Passed {
+			result.Message = fmt.Sprintf("body does not match %q", a.BodyRegex)
+		}
+		results = append(results, result)
+	}
+
+	for _, jp := range a.JSONPath {
+		results = append(results, checkJSONPath(jp, body))
+	}
+
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, checkHeader(name, a.Headers[name], header))
+	}
+
+	return results
+}
+
+// FirstFailure returns the message of the first failed assertion, if any
+func FirstFailure(results []models.AssertionResult) (string, bool) {
+	for _, result := range results {
+		if !result.Passed {
+			return result.Message, true
+		}
+	}
+	return "", false
+}
+
+// checkStatus matches the status code against the configured sets or the 2xx/3xx default
+func checkStatus(a *models.HTTPAssertions, statusCode int) models.AssertionResult {
+	result := models.AssertionResult{Name: "status_code"}
+
+	if a == nil || len(a.StatusCodes) == 0 {
+		result.Passed = statusCode >= 200 && statusCode < 400
+	} else {
+		for _, spec := range a.StatusCodes {
+			low, high, err := parseStatusSpec(spec)
+			if err == nil && statusCode >= low && statusCode <= high {
+				result.Passed = true
+				break
+			}
+		}
+	}
+
+	if !result.Passed {
+		result.Message = fmt.Sprintf("HTTP %d", statusCode)
+	}
+	return result
+}
+
+// parseStatusSpec parses "200", "2xx" or "200-299" into an inclusive range
+func parseStatusSpec(spec string) (int, int, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+
+	if len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5' {
+		base := int(s[0]-'0') * 100
+		return base, base + 99, nil
+	}
+
+	if low, high, ok := strings.Cut(s, "-"); ok {
+		l, err1 := strconv.Atoi(strings.TrimSpace(low))
+		h, err2 := strconv.Atoi(strings.TrimSpace(high))
+		if err1 != nil || err2 != nil || l > h {
+			return 0, 0, fmt.Errorf("invalid status code range %q", spec)
+		}
+		return l, h, nil
+	}
+
+	code, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status code %q", spec)
+	}
+	return code, code, nil
+}
+
+// checkJSONPath compares the value at a JSONPath expression with the expected value
+func checkJSONPath(jp models.JSONPathAssertion, body []byte) models.AssertionResult {
+	result := models.AssertionResult{Name: "json_path " + jp.Path}
+
+	value, err := lookupJSONPath(body, jp.Path)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	if jp.Value == "" {
+		result.Passed = true
+		return result
+	}
+
+	actual := formatJSONValue(value)
+	if result.Passed = actual == jp.Value; !result.Passed {
+		result.Message = fmt.Sprintf("%s is %q, expected %q", jp.Path, actual, jp.Value)
+	}
+	return result
+}
+
+// checkHeader requires a response header, optionally matching a pattern
+func checkHeader(name, pattern string, header http.Header) models.AssertionResult {
+	result := models.AssertionResult{Name: "header " + name}
+
+	values, ok := header[http.CanonicalHeaderKey(name)]
+	if !ok {
+		result.Message = fmt.Sprintf("header %s missing", name)
+		return result
+	}
+
+	if pattern == "" {
+		result.Passed = true
+		return result
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	for _, value := range values {
+		if re.MatchString(value) {
+			result.Passed = true
+			return result
+		}
+	}
+	result.Message = fmt.Sprintf("header %s does not match %q", name, pattern)
+	return result
+}
@@ -0,0 +1,119 @@
+package assertions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep is a single object key or array index in a JSONPath expression
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses the dot/bracket subset of JSONPath, e.g. $.items[0].status
+// or $['status']
+func parseJSONPath(path string) ([]pathStep, error) {
+	p := strings.TrimSpace(path)
+	p = strings.TrimPrefix(p, "$")
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '.' && p[0] != '[' {
+		p = "." + p
+	}
+
+	var steps []pathStep
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty key", path)
+			}
+			steps = append(steps, pathStep{key: p[:end]})
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: missing ]", path)
+			}
+			inner := p[1:end]
+			p = p[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: bad index %q", path, inner)
+			}
+			steps = append(steps, pathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q", path)
+		}
+	}
+	return steps, nil
+}
+
+// lookupJSONPath returns the value at path within a JSON document
+func lookupJSONPath(body []byte, path string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("body is not valid JSON: %v", err)
+	}
+
+	for _, step := range steps {
+		if step.isIndex {
+			list, ok := value.([]interface{})
+			if !ok || step.index >= len(list) {
+				return nil, fmt.Errorf("%s not found", path)
+			}
+			value = list[step.index]
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+		if value, ok = object[step.key]; !ok {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+	}
+	return value, nil
+}
+
+// formatJSONValue renders a JSON value for comparison with an expected string
+func formatJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
@@ -1,31 +1,69 @@
 package config
 
 import (
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"health-caretaker/internal/alerting"
+	"health-caretaker/internal/assertions"
+	"health-caretaker/internal/maintenance"
 	"health-caretaker/internal/models"
+	"health-caretaker/internal/notify"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Endpoints []EndpointConfig `json:"endpoints"`
-	Server    ServerConfig     `json:"server"`
-	Metrics   MetricsConfig    `json:"metrics"`
+	Endpoints     []EndpointConfig            `json:"endpoints"`
+	Templates     map[string]EndpointTemplate `json:"templates,omitempty"` // Shared endpoint settings by name
+	Server        ServerConfig                `json:"server"`
+	Metrics       MetricsConfig               `json:"metrics"`
+	Concurrency   ConcurrencyConfig           `json:"concurrency"`
+	History       HistoryConfig               `json:"history"`
+	Storage       StorageConfig               `json:"storage"`
+	Notifications NotificationsConfig         `json:"notifications"`
+	Maintenance   []models.MaintenanceWindow  `json:"maintenance"`
+
+	// Where each endpoint, template and maintenance window was defined
+	endpointSources    []position
+	templateSources    map[string]position
+	maintenanceSources []position
+
+	references []models.Reference // Strings that contained ${...} references
 }
 
 // EndpointConfig represents a single endpoint configuration
 type EndpointConfig struct {
-	Name      string            `json:"name"`
-	URL       string            `json:"url"`
-	Method    string            `json:"method"`
-	Interval  int               `json:"interval"`
-	Timeout   int               `json:"timeout"`
-	Labels    map[string]string `json:"labels,omitempty"`     // Additional labels for metrics
-	ProbeType string            `json:"probe_type,omitempty"` // e.g., "livez", "readyz", "healthz"
+	ID               string                 `json:"id,omitempty"` // Stable ID; derived from the name and URL when unset
+	Name             string                 `json:"name"`
+	URL              string                 `json:"url"`
+	Template         string                 `json:"template,omitempty"` // Template the unset settings are taken from
+	Method           string                 `json:"method"`
+	Interval         float64                `json:"interval"`
+	Timeout          int                    `json:"timeout"`
+	Labels           map[string]string      `json:"labels,omitempty"`            // Additional labels for metrics
+	ProbeType        string                 `json:"probe_type,omitempty"`        // e.g., "livez", "readyz", "healthz"
+	TCP              *models.TCPProbe       `json:"tcp,omitempty"`               // Options for tcp:// endpoints
+	DNS              *models.DNSProbe       `json:"dns,omitempty"`               // Options for dns:// endpoints
+	GRPC             *models.GRPCProbe      `json:"grpc,omitempty"`              // Options for grpc:// and grpcs:// endpoints
+	TLS              *models.TLSPolicy      `json:"tls,omitempty"`               // TLS policy for https:// endpoints
+	Assertions       *models.HTTPAssertions `json:"assertions,omitempty"`        // Checks on the HTTP response
+	Headers          map[string]string      `json:"headers,omitempty"`           // Request headers for HTTP probes
+	Body             string                 `json:"body,omitempty"`              // Inline request body
+	BodyFile         string                 `json:"body_file,omitempty"`         // File holding the request body
+	Auth             *models.HTTPAuth       `json:"auth,omitempty"`              // Request authentication
+	Retry            *models.RetryPolicy    `json:"retry,omitempty"`             // Retries within a single check
+	FailureThreshold int                    `json:"failure_threshold,omitempty"` // Consecutive failed checks before going down
+	SuccessThreshold int                    `json:"success_threshold,omitempty"` // Consecutive successful checks before recovering
+
+	references []models.Reference // Strings that contained ${...} references, relative to the endpoint
 }
 
 // ServerConfig represents server configuration
@@ -40,12 +78,46 @@ type MetricsConfig struct {
 	Port    string `json:"port"`
 }
 
-// LoadConfig loads configuration from a JSON file with environment variable overrides
+// ConcurrencyConfig limits how many probes run at the same time
+type ConcurrencyConfig struct {
+	MaxConcurrent int            `json:"max_concurrent"`         // Total concurrent probes (default 100)
+	PerHost       int            `json:"per_host,omitempty"`     // Concurrent probes per target host
+	GroupLabel    string         `json:"group_label,omitempty"`  // Label that groups endpoints, e.g. "team"
+	PerGroup      int            `json:"per_group,omitempty"`    // Default concurrent probes per group
+	GroupLimits   map[string]int `json:"group_limits,omitempty"` // Per-group overrides keyed by label value
+}
+
+// HistoryConfig controls how many check results are kept per endpoint
+type HistoryConfig struct {
+	Size             int `json:"size"`              // Results kept per endpoint (default 1000)
+	WebSocketResults int `json:"websocket_results"` // Results sent per endpoint when a dashboard connects (default 30)
+}
+
+// StorageConfig selects where endpoints, check results and incidents are persisted
+type StorageConfig struct {
+	Backend       string `json:"backend,omitempty"`        // "bolt", or empty to keep state in memory only
+	Path          string `json:"path,omitempty"`           // Database file for the bolt backend
+	RetentionDays int    `json:"retention_days,omitempty"` // Days of check results kept (default 90)
+}
+
+// NotificationsConfig configures where status transitions are sent
+type NotificationsConfig struct {
+	DeadLetterFile string          `json:"dead_letter_file,omitempty"` // JSON lines file for undeliverable notifications
+	Notifiers      []notify.Config `json:"notifiers,omitempty"`
+	Route          *alerting.Route `json:"route,omitempty"` // Routing tree; without it every notifier gets every transition
+
+	notifierSources []position // Where each notifier was defined
+}
+
+// LoadConfig loads configuration from a JSON or YAML file with environment
+// variable overrides. The filename may also name a directory or a glob
+// pattern, whose files are merged into one configuration, along with the
+// files they include.
 func LoadConfig(filename string) (*Config, error) {
 	var config *Config
 
 	// Check if file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) && !isPattern(filename) {
 		// Create default config if file doesn't exist
 		config = getDefaultConfig()
 
@@ -54,14 +126,14 @@ func LoadConfig(filename string) (*Config, error) {
 			return nil, fmt.Errorf("failed to create default config: %v", err)
 		}
 	} else {
-		// Read existing config file
-		data, err := ioutil.ReadFile(filename)
+		// Read the config file, or every file in a directory or glob
+		files, err := Files(filename)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read config file: %v", err)
+			return nil, err
 		}
 
-		if err := json.Unmarshal(data, &config); err != nil {
-			return nil, fmt.Errorf("failed to parse config file: %v", err)
+		if config, err = loadFiles(files); err != nil {
+			return nil, err
 		}
 	}
 
@@ -115,6 +187,13 @@ func getDefaultConfig() *Config {
 			Path:    "/metrics",
 			Port:    "9091",
 		},
+		Concurrency: ConcurrencyConfig{
+			MaxConcurrent: 100,
+		},
+		History: HistoryConfig{
+			Size:             1000,
+			WebSocketResults: 30,
+		},
 	}
 }
 
@@ -140,6 +219,21 @@ func applyEnvOverrides(config *Config) {
 	if path := os.Getenv("METRICS_PATH"); path != "" {
 		config.Metrics.Path = path
 	}
+
+	// Storage configuration overrides
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		config.Storage.Backend = backend
+	}
+	if path := os.Getenv("STORAGE_PATH"); path != "" {
+		config.Storage.Path = path
+	}
+
+	// Concurrency configuration overrides
+	if max := os.Getenv("MAX_CONCURRENT_PROBES"); max != "" {
+		if value, err := strconv.Atoi(max); err == nil {
+			config.Concurrency.MaxConcurrent = value
+		}
+	}
 }
 
 // SaveConfig saves configuration to a JSON file
@@ -171,10 +265,135 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	for i, endpoint := range c.Endpoints {
+	if err := c.Concurrency.Validate(); err != nil {
+		return fmt.Errorf("concurrency validation failed: %v", err)
+	}
+
+	if c.History.Size < 0 || c.History.WebSocketResults < 0 {
+		return fmt.Errorf("history sizes must not be negative")
+	}
+	if c.History.Size == 0 {
+		c.History.Size = 1000
+	}
+	if c.History.WebSocketResults == 0 {
+		c.History.WebSocketResults = 30
+	}
+
+	if err := c.Storage.Validate(); err != nil {
+		return fmt.Errorf("storage validation failed: %v", err)
+	}
+
+	if err := c.Notifications.Validate(); err != nil {
+		return fmt.Errorf("notifications validation failed: %v", err)
+	}
+
+	ids := make(map[string]bool, len(c.Maintenance))
+	for i := range c.Maintenance {
+		window := &c.Maintenance[i]
+		if err := maintenance.Validate(window); err != nil {
+			return fmt.Errorf("%s validation failed: %v", describe("maintenance window", c.maintenanceSources, i), err)
+		}
+		if window.ID != "" && ids[window.ID] {
+			return fmt.Errorf("duplicate maintenance window id %q", window.ID)
+		}
+		ids[window.ID] = true
+	}
+
+	if err := c.applyTemplates(); err != nil {
+		return err
+	}
+
+	endpointIDs := make(map[string]int, len(c.Endpoints))
+	for i := range c.Endpoints {
+		endpoint := &c.Endpoints[i]
 		if err := endpoint.Validate(); err != nil {
-			return fmt.Errorf("endpoint %d validation failed: %v", i, err)
+			return fmt.Errorf("%s validation failed: %v", describe("endpoint", c.endpointSources, i), err)
+		}
+		id := endpoint.EndpointID()
+		if first, exists := endpointIDs[id]; exists {
+			return fmt.Errorf("%s validation failed: id %q is already used by endpoint %q", describe("endpoint", c.endpointSources, i), id, c.Endpoints[first].Name)
+		}
+		endpointIDs[id] = i
+		endpoint.references = c.itemReferences("endpoints", i)
+	}
+
+	return nil
+}
+
+// itemReferences returns the references into the i-th item of a list, with
+// paths relative to the item
+func (c *Config) itemReferences(list string, i int) []models.Reference {
+	var references []models.Reference
+	for _, reference := range c.references {
+		if len(reference.Path) > 2 && reference.Path[0] == list && reference.Path[1] == strconv.Itoa(i) {
+			references = append(references, models.Reference{Path: reference.Path[2:], Raw: reference.Raw})
+		}
+	}
+	return references
+}
+
+// Validate validates every notifier and checks that names are unique
+func (nc *NotificationsConfig) Validate() error {
+	names := make(map[string]bool, len(nc.Notifiers))
+	for i := range nc.Notifiers {
+		notifier := &nc.Notifiers[i]
+		if err := notifier.Validate(); err != nil {
+			return fmt.Errorf("%s: %v", describe("notifier", nc.notifierSources, i), err)
 		}
+		if names[notifier.Name] {
+			return fmt.Errorf("duplicate notifier name %q", notifier.Name)
+		}
+		names[notifier.Name] = true
+	}
+
+	if nc.Route != nil {
+		if err := nc.Route.Validate(names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates the storage backend settings
+func (sc *StorageConfig) Validate() error {
+	switch sc.Backend {
+	case "":
+		return nil
+	case "bolt":
+		if sc.Path == "" {
+			return fmt.Errorf("path is required for the bolt backend")
+		}
+	default:
+		return fmt.Errorf("unsupported backend %q", sc.Backend)
+	}
+
+	if sc.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	if sc.RetentionDays == 0 {
+		sc.RetentionDays = 90
+	}
+	return nil
+}
+
+// Validate validates the probe concurrency limits
+func (cc *ConcurrencyConfig) Validate() error {
+	if cc.MaxConcurrent < 0 || cc.PerHost < 0 || cc.PerGroup < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+
+	if cc.MaxConcurrent == 0 {
+		cc.MaxConcurrent = 100
+	}
+
+	for group, limit := range cc.GroupLimits {
+		if limit < 0 {
+			return fmt.Errorf("limit for group %q must not be negative", group)
+		}
+	}
+
+	if (cc.PerGroup > 0 || len(cc.GroupLimits) > 0) && cc.GroupLabel == "" {
+		return fmt.Errorf("group_label is required for per-group limits")
 	}
 
 	return nil
@@ -190,8 +409,48 @@ func (ec *EndpointConfig) Validate() error {
 		return fmt.Errorf("URL is required")
 	}
 
-	if !strings.HasPrefix(ec.URL, "http://") && !strings.HasPrefix(ec.URL, "https://") {
-		return fmt.Errorf("URL must start with http:// or https://")
+	if ec.ID != "" {
+		if err := models.ValidateEndpointID(ec.ID); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(ec.URL, "http://"), strings.HasPrefix(ec.URL, "https://"):
+	case strings.HasPrefix(ec.URL, "tcp://"):
+		if err := ec.validateTCP(); err != nil {
+			return err
+		}
+	case strings.HasPrefix(ec.URL, "dns://"):
+		if err := ec.validateDNS(); err != nil {
+			return err
+		}
+	case strings.HasPrefix(ec.URL, "grpc://"), strings.HasPrefix(ec.URL, "grpcs://"):
+		if err := ec.validateGRPC(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("URL must start with http://, https://, tcp://, dns://, grpc:// or grpcs://")
+	}
+
+	if err := ec.validateTLS(); err != nil {
+		return err
+	}
+
+	if err := assertions.Validate(ec.Assertions); err != nil {
+		return fmt.Errorf("invalid assertions: %v", err)
+	}
+
+	if err := ec.validateRequest(); err != nil {
+		return err
+	}
+
+	if ec.Retry != nil && (ec.Retry.Attempts < 0 || ec.Retry.Backoff < 0 || ec.Retry.MaxBackoff < 0) {
+		return fmt.Errorf("retry attempts and backoff must not be negative")
+	}
+
+	if ec.FailureThreshold < 0 || ec.SuccessThreshold < 0 {
+		return fmt.Errorf("failure_threshold and success_threshold must not be negative")
 	}
 
 	if ec.Method == "" {
@@ -209,16 +468,211 @@ func (ec *EndpointConfig) Validate() error {
 	return nil
 }
 
+// validateTCP validates the address and options of a tcp:// endpoint
+func (ec *EndpointConfig) validateTCP() error {
+	u, err := url.Parse(ec.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+
+	if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" {
+		return fmt.Errorf("tcp URL must be in the form tcp://host:port")
+	}
+
+	if ec.TCP != nil && ec.TCP.Expect != "" {
+		if _, err := regexp.Compile(ec.TCP.Expect); err != nil {
+			return fmt.Errorf("invalid tcp expect pattern: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// validateRequest validates the request body and authentication of an endpoint
+func (ec *EndpointConfig) validateRequest() error {
+	if ec.Body != "" && ec.BodyFile != "" {
+		return fmt.Errorf("only one of body or body_file may be set")
+	}
+
+	if ec.BodyFile != "" {
+		if _, err := os.Stat(ec.BodyFile); err != nil {
+			return fmt.Errorf("body_file: %v", err)
+		}
+	}
+
+	if ec.Auth == nil {
+		return nil
+	}
+
+	configured := 0
+	if ec.Auth.Basic != nil {
+		configured++
+		if ec.Auth.Basic.Username == "" {
+			return fmt.Errorf("auth.basic.username is required")
+		}
+		if _, err := ec.Auth.Basic.Password.Resolve(); err != nil {
+			return fmt.Errorf("auth.basic.password: %v", err)
+		}
+	}
+	if ec.Auth.Bearer != nil {
+		configured++
+		if _, err := ec.Auth.Bearer.Resolve(); err != nil {
+			return fmt.Errorf("auth.bearer: %v", err)
+		}
+	}
+	if oauth := ec.Auth.OAuth2; oauth != nil {
+		configured++
+		if oauth.TokenURL == "" || oauth.ClientID == "" {
+			return fmt.Errorf("auth.oauth2 requires token_url and client_id")
+		}
+		if _, err := oauth.ClientSecret.Resolve(); err != nil {
+			return fmt.Errorf("auth.oauth2.client_secret: %v", err)
+		}
+		switch oauth.AuthStyle {
+		case "", "header", "params":
+		default:
+			return fmt.Errorf("auth.oauth2.auth_style must be header or params")
+		}
+	}
+
+	if configured != 1 {
+		return fmt.Errorf("auth must configure exactly one of basic, bearer or oauth2")
+	}
+
+	return nil
+}
+
+// validateTLS validates the TLS policy of an endpoint
+func (ec *EndpointConfig) validateTLS() error {
+	if ec.TLS == nil {
+		return nil
+	}
+
+	if ec.TLS.CAFile != "" {
+		data, err := ioutil.ReadFile(ec.TLS.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read tls ca_file: %v", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in tls ca_file %s", ec.TLS.CAFile)
+		}
+	}
+
+	if ec.TLS.ExpiryThresholdDays < 0 {
+		return fmt.Errorf("tls expiry_threshold_days must not be negative")
+	}
+
+	switch ec.TLS.ExpiryStatus {
+	case "", "degraded", "down":
+	default:
+		return fmt.Errorf("tls expiry_status must be degraded or down")
+	}
+
+	return nil
+}
+
+// validateGRPC validates the address of a grpc:// or grpcs:// endpoint
+func (ec *EndpointConfig) validateGRPC() error {
+	u, err := url.Parse(ec.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+
+	if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" {
+		return fmt.Errorf("grpc URL must be in the form grpc://host:port or grpcs://host:port")
+	}
+
+	return nil
+}
+
+// validateDNS validates the resolver address and query of a dns:// endpoint
+func (ec *EndpointConfig) validateDNS() error {
+	u, err := url.Parse(ec.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("dns URL must be in the form dns://resolver[:port]")
+	}
+
+	if ec.DNS == nil || ec.DNS.QueryName == "" {
+		return fmt.Errorf("dns.query_name is required for dns:// endpoints")
+	}
+
+	if ec.DNS.QueryType == "" {
+		ec.DNS.QueryType = "A"
+	}
+	ec.DNS.QueryType = strings.ToUpper(ec.DNS.QueryType)
+	switch ec.DNS.QueryType {
+	case "A", "AAAA", "CNAME", "MX", "TXT", "SRV":
+	default:
+		return fmt.Errorf("unsupported dns query_type %q", ec.DNS.QueryType)
+	}
+
+	switch ec.DNS.Transport {
+	case "", "udp", "tcp":
+	default:
+		return fmt.Errorf("dns transport must be udp or tcp")
+	}
+
+	if ec.DNS.ExpectedRcode == "" {
+		ec.DNS.ExpectedRcode = "NOERROR"
+	}
+	ec.DNS.ExpectedRcode = strings.ToUpper(ec.DNS.ExpectedRcode)
+	switch ec.DNS.ExpectedRcode {
+	case "NOERROR", "FORMERR", "SERVFAIL", "NXDOMAIN", "NOTIMP", "REFUSED":
+	default:
+		return fmt.Errorf("unsupported dns expected_rcode %q", ec.DNS.ExpectedRcode)
+	}
+
+	for _, ip := range ec.DNS.ExpectedIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid dns expected_ips entry %q", ip)
+		}
+	}
+
+	if ec.DNS.MinRecords < 0 {
+		return fmt.Errorf("dns min_records must not be negative")
+	}
+
+	return nil
+}
+
+// EndpointID returns the configured ID, or the one derived from the name and URL
+func (ec *EndpointConfig) EndpointID() string {
+	if ec.ID != "" {
+		return ec.ID
+	}
+	return models.EndpointID(ec.Name, ec.URL)
+}
+
 // ToEndpoint converts EndpointConfig to models.Endpoint
 func (ec *EndpointConfig) ToEndpoint() *models.Endpoint {
 	return &models.Endpoint{
-		Name:      ec.Name,
-		URL:       ec.URL,
-		Method:    ec.Method,
-		Interval:  ec.Interval,
-		Timeout:   ec.Timeout,
-		Status:    "checking",
-		Labels:    ec.Labels,
-		ProbeType: ec.ProbeType,
+		ID:         ec.EndpointID(),
+		Name:       ec.Name,
+		URL:        ec.URL,
+		Method:     ec.Method,
+		Interval:   ec.Interval,
+		Timeout:    ec.Timeout,
+		Status:     "checking",
+		Labels:     ec.Labels,
+		ProbeType:  ec.ProbeType,
+		Source:     models.SourceConfig,
+		TCP:        ec.TCP,
+		DNS:        ec.DNS,
+		GRPC:       ec.GRPC,
+		TLS:        ec.TLS,
+		Assertions: ec.Assertions,
+		Headers:    ec.Headers,
+		Body:       ec.Body,
+		BodyFile:   ec.BodyFile,
+		Auth:       ec.Auth,
+
+		Retry:            ec.Retry,
+		FailureThreshold: ec.FailureThreshold,
+		SuccessThreshold: ec.SuccessThreshold,
+		References:       ec.references,
 	}
 }
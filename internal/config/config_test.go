@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"health-caretaker/internal/models"
+)
+
+func TestEndpointID(t *testing.T) {
+	derived := EndpointConfig{Name: "Orders API", URL: "https://orders.internal/healthz"}
+	if got, want := derived.EndpointID(), models.EndpointID(derived.Name, derived.URL); got != want {
+		t.Errorf("EndpointID() = %q, want %q", got, want)
+	}
+	if got := derived.ToEndpoint().ID; got != derived.EndpointID() {
+		t.Errorf("ToEndpoint().ID = %q, want %q", got, derived.EndpointID())
+	}
+
+	explicit := EndpointConfig{ID: "orders", Name: "Orders API", URL: "https://orders.internal/healthz"}
+	if got := explicit.EndpointID(); got != "orders" {
+		t.Errorf("EndpointID() = %q, want the explicit ID", got)
+	}
+}
+
+func TestValidateEndpointIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []EndpointConfig
+		err       string
+	}{
+		{
+			name: "distinct",
+			endpoints: []EndpointConfig{
+				{Name: "a", URL: "http://a.internal/healthz"},
+				{Name: "b", URL: "http://b.internal/healthz"},
+				{ID: "c", Name: "c", URL: "http://c.internal/healthz"},
+			},
+		},
+		{
+			name: "duplicate explicit ID",
+			endpoints: []EndpointConfig{
+				{ID: "orders", Name: "a", URL: "http://a.internal/healthz"},
+				{ID: "orders", Name: "b", URL: "http://b.internal/healthz"},
+			},
+			err: `id "orders" is already used by endpoint "a"`,
+		},
+		{
+			name: "explicit ID equal to a derived one",
+			endpoints: []EndpointConfig{
+				{Name: "a", URL: "http://a.internal/healthz"},
+				{ID: models.EndpointID("a", "http://a.internal/healthz"), Name: "b", URL: "http://b.internal/healthz"},
+			},
+			err: "is already used by endpoint",
+		},
+		{
+			name:      "invalid ID",
+			endpoints: []EndpointConfig{{ID: "orders api", Name: "a", URL: "http://a.internal/healthz"}},
+			err:       "invalid id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := getDefaultConfig()
+			cfg.Endpoints = test.endpoints
+			err := cfg.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,678 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"health-caretaker/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// position is where a value was defined in the configuration files
+type position struct {
+	file  string
+	line  int
+	index int // Index of a list item within its file
+}
+
+func (p position) String() string {
+	if p.line == 0 {
+		return p.file
+	}
+	return fmt.Sprintf("%s:%d", p.file, p.line)
+}
+
+// prefix returns the position for the start of an error message, or nothing
+// when the position is unknown
+func (p position) prefix() string {
+	if p.file == "" {
+		return ""
+	}
+	return p.String() + ": "
+}
+
+// fragment is a single configuration file
+type fragment struct {
+	file       string
+	config     Config
+	lines      map[string]int     // Line of each value by dotted path, e.g. "endpoints.2"
+	references []models.Reference // Strings that contained ${...} references
+	includes   []string           // Files, directories or globs listed under include
+}
+
+// at returns the position of the value at path
+func (f *fragment) at(path string) position {
+	return position{file: f.file, line: f.lines[path]}
+}
+
+// isPattern reports whether a configuration path is a glob pattern
+func isPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// isConfigFile reports whether a file name has a configuration file extension
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Files returns the configuration files a path refers to: the file itself,
+// the .json, .yaml and .yml files in a directory, or the files matching a
+// glob pattern, in lexical order
+func Files(path string) ([]string, error) {
+	if isPattern(path) {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config pattern %q: %v", path, err)
+		}
+		var files []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no config files match %s", path)
+		}
+		return files, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %v", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		// Hidden entries include the ..data directories of a ConfigMap mount
+		if strings.HasPrefix(entry.Name(), ".") || !isConfigFile(entry.Name()) {
+			continue
+		}
+		file := filepath.Join(path, entry.Name())
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files in %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadFiles parses configuration files, and the files they include, and
+// merges them into one configuration
+func loadFiles(files []string) (*Config, error) {
+	l := &loader{loaded: make(map[string]bool)}
+	for _, file := range files {
+		if err := l.load(file, nil, position{}); err != nil {
+			return nil, err
+		}
+	}
+	return merge(l.fragments)
+}
+
+// loader reads configuration files and the files they include, in order
+type loader struct {
+	fragments []*fragment
+	loaded    map[string]bool // Files already read, by absolute path
+}
+
+// load reads a file and then the files it includes. stack holds the files
+// including it, so that a file including itself, directly or not, is
+// reported rather than read again. A file already read elsewhere is skipped.
+func (l *loader) load(file string, stack []string, from position) error {
+	key, err := filepath.Abs(file)
+	if err != nil {
+		key = filepath.Clean(file)
+	}
+	for i, including := range stack {
+		if including == key {
+			cycle := append(append([]string{}, stack[i:]...), key)
+			return fmt.Errorf("%s: include cycle: %s", from, strings.Join(cycle, " -> "))
+		}
+	}
+	if l.loaded[key] {
+		return nil
+	}
+	l.loaded[key] = true
+
+	f, err := parseFile(file)
+	if err != nil {
+		return err
+	}
+	l.fragments = append(l.fragments, f)
+
+	stack = append(stack, key)
+	for i, include := range f.includes {
+		at := f.at(join("include", strconv.Itoa(i)))
+		files, err := Files(includePath(file, include))
+		if err != nil {
+			return fmt.Errorf("%s: include %q: %v", at, include, err)
+		}
+		for _, included := range files {
+			if err := l.load(included, stack, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includePath resolves an include entry relative to the including file
+func includePath(file, include string) string {
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(file), include)
+}
+
+// includes lists the include entries of a configuration file
+type includes struct {
+	Include []string `json:"include"`
+}
+
+// configFiles returns the files the configuration at path is read from,
+// including the files they include. Files that cannot be parsed are returned
+// without following their includes.
+func configFiles(path string) ([]string, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var all []string
+	var visit func(file string)
+	visit = func(file string) {
+		key, err := filepath.Abs(file)
+		if err != nil {
+			key = filepath.Clean(file)
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		all = append(all, file)
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml":
+			if data, _, _, err = yamlToJSON(data); err != nil {
+				return
+			}
+		}
+		var list includes
+		if json.Unmarshal(data, &list) != nil {
+			return
+		}
+		for _, include := range list.Include {
+			included, _ := Files(includePath(file, include))
+			for _, file := range included {
+				visit(file)
+			}
+		}
+	}
+	for _, file := range files {
+		visit(file)
+	}
+	return all, nil
+}
+
+// parseFile reads a JSON or YAML configuration file and expands the
+// references in its values
+func parseFile(file string) (*fragment, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	f := &fragment{file: file}
+	var offsets []lineOffset
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		data, f.lines, offsets, err = yamlToJSON(data)
+		if e, ok := err.(*lineError); ok {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", position{file: file, line: e.line}, e.err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", file, err)
+		}
+	default:
+		offsets = jsonOffsets(data)
+		if f.lines, err = jsonLines(data, offsets); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", parseErrorPosition(file, err, offsets), jsonError(err))
+		}
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return f, nil
+	}
+	if err := json.Unmarshal(data, &f.config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", parseErrorPosition(file, err, offsets), jsonError(err))
+	}
+	var list includes
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: include must be a list of files, directories or globs", f.at("include"))
+	}
+	f.includes = list.Include
+
+	if err := f.interpolate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %v", err)
+	}
+	return f, nil
+}
+
+// parseErrorPosition returns the file and, where the error carries an
+// offset, the line the error occurred at
+func parseErrorPosition(file string, err error, offsets []lineOffset) position {
+	p := position{file: file}
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		p.line = lineAt(offsets, e.Offset)
+	case *json.UnmarshalTypeError:
+		p.line = lineAt(offsets, e.Offset)
+	}
+	return p
+}
+
+// jsonError describes a decoding error in terms of the configuration keys
+func jsonError(err error) error {
+	if e, ok := err.(*json.UnmarshalTypeError); ok && e.Field != "" {
+		return fmt.Errorf("%s must be %s, not %s", e.Field, jsonType(e.Type.Kind().String()), e.Value)
+	}
+	return err
+}
+
+// jsonType names a Go kind the way it is written in a configuration file
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "string":
+		return "a string"
+	case kind == "slice", kind == "array":
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+// lineOffset maps a byte offset of the decoded JSON to a source line
+type lineOffset struct {
+	offset int64
+	line   int
+}
+
+// lineAt returns the source line of a byte offset
+func lineAt(offsets []lineOffset, offset int64) int {
+	i := sort.Search(len(offsets), func(i int) bool { return offsets[i].offset > offset })
+	if i == 0 {
+		return 0
+	}
+	return offsets[i-1].line
+}
+
+// jsonOffsets returns the offset at which every line of a JSON file starts
+func jsonOffsets(data []byte) []lineOffset {
+	offsets := []lineOffset{{offset: 0, line: 1}}
+	for i, b := range data {
+		if b == '\n' {
+			offsets = append(offsets, lineOffset{offset: int64(i + 1), line: len(offsets) + 1})
+		}
+	}
+	return offsets
+}
+
+// jsonLines returns the line of every value in a JSON document by path
+func jsonLines(data []byte, offsets []lineOffset) (map[string]int, error) {
+	lines := make(map[string]int)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return lines, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var walk func(path string) error
+	walk = func(path string) error {
+		start := decoder.InputOffset()
+		for start < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[start]) >= 0 {
+			start++
+		}
+		if path != "" {
+			lines[path] = lineAt(offsets, start)
+		}
+
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(join(path, key.(string))); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(join(path, strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+		_, err = decoder.Token()
+		return err
+	}
+	return lines, walk("")
+}
+
+// yamlErrorPattern matches the line number in a YAML syntax error
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// lineError is an error at a line of a YAML file
+type lineError struct {
+	line int
+	err  error
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+// yamlToJSON converts a YAML document to JSON so that it is decoded with the
+// same field names and rules as a JSON file. It returns the line of every
+// value by path and the source lines of the JSON output for error messages.
+func yamlToJSON(data []byte) ([]byte, map[string]int, []lineOffset, error) {
+	lines := make(map[string]int)
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		// Syntax errors read "yaml: line N: message"
+		if match := yamlErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			return nil, nil, nil, &lineError{line: line, err: fmt.Errorf("%s", match[2])}
+		}
+		return nil, nil, nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, lines, nil, nil
+	}
+
+	c := &yamlConverter{lines: lines}
+	if err := c.convert(document.Content[0], ""); err != nil {
+		return nil, nil, nil, err
+	}
+	return c.out.Bytes(), lines, c.offsets, nil
+}
+
+// yamlConverter writes a YAML node tree as JSON
+type yamlConverter struct {
+	out     bytes.Buffer
+	lines   map[string]int
+	offsets []lineOffset
+}
+
+// mark records that the output written next comes from a source line
+func (c *yamlConverter) mark(line int) {
+	c.offsets = append(c.offsets, lineOffset{offset: int64(c.out.Len()), line: line})
+}
+
+func (c *yamlConverter) convert(node *yaml.Node, path string) error {
+	if _, recorded := c.lines[path]; path != "" && !recorded {
+		c.lines[path] = node.Line
+	}
+	c.mark(node.Line)
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return c.convert(node.Alias, path)
+
+	case yaml.MappingNode:
+		pairs, err := mappingPairs(node)
+		if err != nil {
+			return err
+		}
+		c.out.WriteByte('{')
+		for i := 0; i < len(pairs); i += 2 {
+			if i > 0 {
+				c.out.WriteByte(',')
+			}
+			key, _ := json.Marshal(pairs[i].Value)
+			c.mark(pairs[i].Line)
+			c.out.Write(key)
+			c.out.WriteByte(':')
+			// Values are located by their key, which is where a block
+			// mapping or sequence begins
+			child := join(path, pairs[i].Value)
+			c.lines[child] = pairs[i].Line
+			if err := c.convert(pairs[i+1], child); err != nil {
+				return err
+			}
+		}
+		c.out.WriteByte('}')
+
+	case yaml.SequenceNode:
+		c.out.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				c.out.WriteByte(',')
+			}
+			if err := c.convert(item, join(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		c.out.WriteByte(']')
+
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return &lineError{line: node.Line, err: err}
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return &lineError{line: node.Line, err: err}
+		}
+		c.out.Write(encoded)
+	}
+	return nil
+}
+
+// mappingPairs returns the keys and values of a mapping, with the pairs of
+// merge keys ("<<: *anchor") first so that the mapping's own keys override them
+func mappingPairs(node *yaml.Node) ([]*yaml.Node, error) {
+	var merged, own []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Tag != "!!merge" {
+			own = append(own, key, value)
+			continue
+		}
+
+		sources := []*yaml.Node{value}
+		if resolve(value).Kind == yaml.SequenceNode {
+			sources = resolve(value).Content
+		}
+		for _, source := range sources {
+			source = resolve(source)
+			if source.Kind != yaml.MappingNode {
+				return nil, &lineError{line: key.Line, err: fmt.Errorf("merge key requires a mapping")}
+			}
+			pairs, err := mappingPairs(source)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, pairs...)
+		}
+	}
+	return append(merged, own...), nil
+}
+
+// resolve follows an alias to the node it refers to
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// join appends a key to a dotted path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// merge combines configuration fragments. Endpoints, templates, notifiers
+// and maintenance windows are collected from every fragment and must have
+// unique names; every other section may only be defined in one fragment.
+func merge(fragments []*fragment) (*Config, error) {
+	config := &Config{}
+
+	sections := []struct {
+		path string
+		copy func(dst, src *Config)
+	}{
+		{"server", func(dst, src *Config) { dst.Server = src.Server }},
+		{"metrics", func(dst, src *Config) { dst.Metrics = src.Metrics }},
+		{"concurrency", func(dst, src *Config) { dst.Concurrency = src.Concurrency }},
+		{"history", func(dst, src *Config) { dst.History = src.History }},
+		{"storage", func(dst, src *Config) { dst.Storage = src.Storage }},
+		{"notifications.dead_letter_file", func(dst, src *Config) {
+			dst.Notifications.DeadLetterFile = src.Notifications.DeadLetterFile
+		}},
+		{"notifications.route", func(dst, src *Config) { dst.Notifications.Route = src.Notifications.Route }},
+	}
+	for _, section := range sections {
+		var owner *fragment
+		for _, f := range fragments {
+			if _, defined := f.lines[section.path]; !defined {
+				continue
+			}
+			if owner != nil {
+				return nil, fmt.Errorf("%s: %s is already defined at %s", f.at(section.path), section.path, owner.at(section.path))
+			}
+			owner = f
+			section.copy(config, &f.config)
+		}
+	}
+
+	endpoints := make(map[string]position)
+	notifiers := make(map[string]position)
+	windows := make(map[string]position)
+	for _, f := range fragments {
+		// References into lists are moved to the items' merged positions
+		offsets := map[string]int{
+			"endpoints":               len(config.Endpoints),
+			"notifications.notifiers": len(config.Notifications.Notifiers),
+			"maintenance":             len(config.Maintenance),
+		}
+		for _, reference := range f.references {
+			config.references = append(config.references, rebase(reference, offsets))
+		}
+
+		for name, template := range f.config.Templates {
+			p := f.at(join("templates", name))
+			if first, exists := config.templateSources[name]; exists {
+				return nil, fmt.Errorf("%s: duplicate template name %q, first defined at %s", p, name, first)
+			}
+			if config.Templates == nil {
+				config.Templates = make(map[string]EndpointTemplate)
+				config.templateSources = make(map[string]position)
+			}
+			config.Templates[name] = template
+			config.templateSources[name] = p
+		}
+
+		for i, endpoint := range f.config.Endpoints {
+			p := f.at(join("endpoints", strconv.Itoa(i)))
+			p.index = i
+			if first, exists := endpoints[endpoint.Name]; exists && endpoint.Name != "" {
+				return nil, fmt.Errorf("%s: duplicate endpoint name %q, first defined at %s", p, endpoint.Name, first)
+			}
+			endpoints[endpoint.Name] = p
+			config.Endpoints = append(config.Endpoints, endpoint)
+			config.endpointSources = append(config.endpointSources, p)
+		}
+
+		for i, notifier := range f.config.Notifications.Notifiers {
+			p := f.at(join("notifications.notifiers", strconv.Itoa(i)))
+			p.index = i
+			if first, exists := notifiers[notifier.Name]; exists && notifier.Name != "" {
+				return nil, fmt.Errorf("%s: duplicate notifier name %q, first defined at %s", p, notifier.Name, first)
+			}
+			notifiers[notifier.Name] = p
+			config.Notifications.Notifiers = append(config.Notifications.Notifiers, notifier)
+			config.Notifications.notifierSources = append(config.Notifications.notifierSources, p)
+		}
+
+		for i, window := range f.config.Maintenance {
+			p := f.at(join("maintenance", strconv.Itoa(i)))
+			p.index = i
+			if first, exists := windows[window.ID]; exists && window.ID != "" {
+				return nil, fmt.Errorf("%s: duplicate maintenance window id %q, first defined at %s", p, window.ID, first)
+			}
+			windows[window.ID] = p
+			config.Maintenance = append(config.Maintenance, window)
+			config.maintenanceSources = append(config.maintenanceSources, p)
+		}
+	}
+
+	return config, nil
+}
+
+// rebase shifts the list index in a reference's path by the offset of its list
+func rebase(reference models.Reference, offsets map[string]int) models.Reference {
+	for list, offset := range offsets {
+		prefix := strings.Split(list, ".")
+		if len(reference.Path) <= len(prefix) || strings.Join(reference.Path[:len(prefix)], ".") != list {
+			continue
+		}
+		index, err := strconv.Atoi(reference.Path[len(prefix)])
+		if err != nil {
+			continue
+		}
+		path := append([]string{}, reference.Path...)
+		path[len(prefix)] = strconv.Itoa(index + offset)
+		return models.Reference{Path: path, Raw: reference.Raw}
+	}
+	return reference
+}
+
+// describe names the i-th item of a list for an error message, with the file
+// and line it was defined at when the configuration was loaded from files
+func describe(kind string, positions []position, i int) string {
+	if i >= len(positions) {
+		return fmt.Sprintf("%s %d", kind, i)
+	}
+	return fmt.Sprintf("%s: %s %d", positions[i], kind, positions[i].index)
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files by name into a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"b.json":       `{"endpoints": [{"name": "search", "url": "http://search.internal/healthz", "interval": 15}]}`,
+		"a.yaml":       "x-defaults: &defaults\n  method: HEAD\n  interval: 60\n\nserver:\n  port: \"9090\"\n\nendpoints:\n  - <<: *defaults\n    name: orders\n    url: http://orders.internal/healthz\n",
+		".hidden.yaml": "endpoints:\n  - name: hidden\n    url: http://hidden.internal/healthz\n",
+		"notes.txt":    "not a config file",
+	})
+
+	config, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(config.Endpoints) != 2 {
+		t.Fatalf("endpoints = %+v, want orders and search", config.Endpoints)
+	}
+	orders, search := config.Endpoints[0], config.Endpoints[1]
+	if orders.Name != "orders" || orders.Method != "HEAD" || orders.Interval != 60 {
+		t.Errorf("orders = %+v, want the merged anchor defaults", orders)
+	}
+	if search.Name != "search" || search.Interval != 15 {
+		t.Errorf("search = %+v", search)
+	}
+	if config.Server.Port != "9090" {
+		t.Errorf("server port = %q, want 9090", config.Server.Port)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "duplicate endpoint name",
+			files: map[string]string{
+				"a.yaml": "endpoints:\n  - name: orders\n    url: http://a.internal/healthz\n",
+				"b.yaml": "server:\n  port: \"9090\"\nendpoints:\n  - name: orders\n    url: http://b.internal/healthz\n",
+			},
+			err: `b.yaml:4: duplicate endpoint name "orders", first defined at `,
+		},
+		{
+			name: "section in two files",
+			files: map[string]string{
+				"a.yaml": "server:\n  port: \"9090\"\n",
+				"b.json": "{\n  \"server\": {\"port\": \"9091\"}\n}",
+			},
+			err: "b.json:2: server is already defined at ",
+		},
+		{
+			name:  "invalid YAML",
+			files: map[string]string{"a.yaml": "server:\n  port: \"8080\"\nendpoints:\n\t- name: orders\n"},
+			err:   "a.yaml:4",
+		},
+		{
+			name:  "validation error",
+			files: map[string]string{"a.yaml": "server:\n  port: \"8080\"\nendpoints:\n  - name: orders\n  - name: search\n    url: http://search.internal/healthz\n"},
+			err:   "a.yaml:4: endpoint 0 validation failed: URL is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeFiles(t, tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("LoadConfig() error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.yaml":        "include:\n  - endpoints/*.yaml\n  - shared.json\nendpoints:\n  - name: main\n    url: http://main.internal/healthz\n",
+		"endpoints/a.yaml": "endpoints:\n  - name: a\n    url: http://a.internal/healthz\n",
+		"endpoints/b.yaml": "include: [../shared.json]\nendpoints:\n  - name: b\n    url: http://b.internal/healthz\n",
+		"shared.json":      `{"endpoints": [{"name": "shared", "url": "http://shared.internal/healthz"}]}`,
+	})
+
+	files, err := Files(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadFiles(files)
+	if err != nil {
+		t.Fatalf("loadFiles() error = %v", err)
+	}
+	var names []string
+	for _, endpoint := range config.Endpoints {
+		names = append(names, endpoint.Name)
+	}
+	// shared.json is included twice but read once
+	if got, want := strings.Join(names, ","), "main,a,b,shared"; got != want {
+		t.Errorf("endpoints = %s, want %s", got, want)
+	}
+
+	watched, err := configFiles(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(watched) != 4 {
+		t.Errorf("configFiles() = %v, want the 4 files", watched)
+	}
+}
+
+func TestLoadIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.yaml": "include: [a.yaml]\n",
+				"a.yaml":    "include:\n  - b.yaml\n",
+				"b.yaml":    "endpoints: []\ninclude:\n  - a.yaml\n",
+			},
+			err: "b.yaml:3: include cycle: ",
+		},
+		{
+			name:  "self",
+			files: map[string]string{"main.yaml": "include:\n  - main.yaml\n"},
+			err:   "main.yaml:2: include cycle: ",
+		},
+		{
+			name:  "missing",
+			files: map[string]string{"main.yaml": "endpoints: []\ninclude:\n  - missing.yaml\n"},
+			err:   `main.yaml:3: include "missing.yaml": failed to read config file`,
+		},
+		{
+			name:  "not a list",
+			files: map[string]string{"main.json": "{\n  \"include\": 3\n}"},
+			err:   "main.json:2: include must be a list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			main := filepath.Join(dir, "main.yaml")
+			if _, ok := tt.files["main.json"]; ok {
+				main = filepath.Join(dir, "main.json")
+			}
+			_, err := loadFiles([]string{main})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("loadFiles() error = %v, want it to contain %q", err, tt.err)
+			}
+			if tt.name == "cycle" && !strings.HasSuffix(err.Error(), "a.yaml -> "+filepath.Join(dir, "b.yaml")+" -> "+filepath.Join(dir, "a.yaml")) {
+				t.Errorf("loadFiles() error = %v, want the cycle from a.yaml", err)
+			}
+		})
+	}
+}
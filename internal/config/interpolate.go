@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"health-caretaker/internal/models"
+)
+
+// referencePattern matches ${VAR}, ${VAR:-default} and ${file:/path}, and
+// the escaped form $${...}, which stands for a literal ${...}
+var referencePattern = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// variablePattern matches an environment variable name
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// expand replaces the references in a string with their values and reports
+// whether it contained any
+func (f *fragment) expand(s string) (string, bool, error) {
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+
+	var err error
+	referenced := false
+	result := referencePattern.ReplaceAllStringFunc(s, func(reference string) string {
+		if strings.HasPrefix(reference, "$$") {
+			return reference[1:]
+		}
+		referenced = true
+		value, resolveErr := resolveReference(reference[2 : len(reference)-1])
+		if resolveErr != nil && err == nil {
+			err = resolveErr
+		}
+		return value
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	if strings.Contains(referencePattern.ReplaceAllString(s, ""), "${") {
+		return "", false, fmt.Errorf("unterminated reference in %q", s)
+	}
+	return result, referenced, nil
+}
+
+// resolveReference returns the value of the expression inside ${...}
+func resolveReference(expression string) (string, error) {
+	if path := strings.TrimPrefix(expression, "file:"); path != expression {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read ${file:%s}: %v", path, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	name, fallback, hasDefault := strings.Cut(expression, ":-")
+	if !variablePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid reference ${%s}", expression)
+	}
+
+	value, set := os.LookupEnv(name)
+	switch {
+	case value != "":
+		return value, nil
+	case hasDefault:
+		return fallback, nil
+	case set:
+		return "", nil
+	default:
+		return "", fmt.Errorf("environment variable %s is not set, referenced as ${%s}", name, expression)
+	}
+}
+
+// interpolate expands the references in every string of the fragment's
+// configuration, recording the raw form of each string that contained one
+func (f *fragment) interpolate() error {
+	return f.interpolateValue(reflect.ValueOf(&f.config).Elem(), nil)
+}
+
+func (f *fragment) interpolateValue(v reflect.Value, path []string) error {
+	switch v.Kind() {
+	case reflect.String:
+		raw := v.String()
+		value, referenced, err := f.expand(raw)
+		if err != nil {
+			dotted := strings.Join(path, ".")
+			return fmt.Errorf("%s: %s: %v", f.at(dotted), dotted, err)
+		}
+		if referenced {
+			f.references = append(f.references, models.Reference{Path: path, Raw: raw})
+		}
+		v.SetString(value)
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return f.interpolateValue(v.Elem(), path)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.PkgPath != "" || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if err := f.interpolateValue(v.Field(i), extend(path, name)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := f.interpolateValue(v.Index(i), extend(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		// Map values are not addressable, so each one is expanded in a copy
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			if err := f.interpolateValue(value, extend(path, fmt.Sprint(iter.Key()))); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+	return nil
+}
+
+// extend returns a copy of path with key appended
+func extend(path []string, key string) []string {
+	extended := make([]string, len(path), len(path)+1)
+	copy(extended, path)
+	return append(extended, key)
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolation(t *testing.T) {
+	os.Setenv("TEST_ORDERS_HOST", "orders.internal")
+	os.Setenv("TEST_EMPTY", "")
+	defer os.Unsetenv("TEST_ORDERS_HOST")
+	defer os.Unsetenv("TEST_EMPTY")
+
+	dir := writeFiles(t, map[string]string{"team": "shop\n"})
+	config, err := LoadConfig(filepath.Join(writeFiles(t, map[string]string{
+		"config.yaml": "server:\n  port: \"8080\"\nendpoints:\n" +
+			"  - name: orders\n" +
+			"    url: https://${TEST_ORDERS_HOST}/healthz\n" +
+			"    labels:\n" +
+			"      team: ${file:" + filepath.Join(dir, "team") + "}\n" +
+			"      env: ${TEST_UNSET_ENV:-staging}\n" +
+			"      empty: ${TEST_EMPTY:-fallback}\n" +
+			"      literal: $${TEST_ORDERS_HOST}\n",
+	}), "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	endpoint := config.Endpoints[0]
+	if endpoint.URL != "https://orders.internal/healthz" {
+		t.Errorf("url = %q", endpoint.URL)
+	}
+	want := map[string]string{"team": "shop", "env": "staging", "empty": "fallback", "literal": "${TEST_ORDERS_HOST}"}
+	for name, value := range want {
+		if endpoint.Labels[name] != value {
+			t.Errorf("label %s = %q, want %q", name, endpoint.Labels[name], value)
+		}
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{name: "unset variable", value: "${TEST_UNSET_ENV}", err: "config.yaml:5: endpoints.0.url: environment variable TEST_UNSET_ENV is not set"},
+		{name: "missing file", value: "${file:/nonexistent/secret}", err: "config.yaml:5: endpoints.0.url: failed to read ${file:/nonexistent/secret}"},
+		{name: "invalid name", value: "${not a name}", err: "config.yaml:5: endpoints.0.url: invalid reference ${not a name}"},
+		{name: "unterminated", value: "http://${TEST_UNSET_ENV", err: "config.yaml:5: endpoints.0.url: unterminated reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{
+				"config.yaml": "server:\n  port: \"8080\"\nendpoints:\n  - name: orders\n    url: \"" + tt.value + "\"\n",
+			})
+			_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("LoadConfig() error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
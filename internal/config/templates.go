@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"health-caretaker/internal/models"
+)
+
+// EndpointTemplate holds settings shared by the endpoints that name it in
+// their template field. Settings an endpoint sets itself take precedence.
+type EndpointTemplate struct {
+	Method     string                 `json:"method,omitempty"`
+	Interval   float64                `json:"interval,omitempty"`
+	Timeout    int                    `json:"timeout,omitempty"`
+	Labels     map[string]string      `json:"labels,omitempty"`     // Merged with the endpoint's labels, which win on conflicts
+	ProbeType  string                 `json:"probe_type,omitempty"` // e.g., "livez", "readyz", "healthz"
+	Assertions *models.HTTPAssertions `json:"assertions,omitempty"` // Used when the endpoint sets no assertions
+}
+
+// Validate validates a template's settings
+func (t *EndpointTemplate) Validate() error {
+	if t.Interval < 0 || t.Timeout < 0 {
+		return fmt.Errorf("interval and timeout must not be negative")
+	}
+	return nil
+}
+
+// applyTemplates fills the settings each endpoint leaves unset from its template
+func (c *Config) applyTemplates() error {
+	for name, template := range c.Templates {
+		if err := template.Validate(); err != nil {
+			return fmt.Errorf("%stemplate %q validation failed: %v", c.templateSources[name].prefix(), name, err)
+		}
+	}
+
+	for i := range c.Endpoints {
+		endpoint := &c.Endpoints[i]
+		if endpoint.Template == "" {
+			continue
+		}
+		template, exists := c.Templates[endpoint.Template]
+		if !exists {
+			return fmt.Errorf("%s validation failed: unknown template %q", describe("endpoint", c.endpointSources, i), endpoint.Template)
+		}
+		before := *endpoint
+		endpoint.inherit(&template)
+		c.inheritReferences(&before, i)
+	}
+	return nil
+}
+
+// inheritReferences adds the references of the settings the i-th endpoint
+// inherited from its template, given the endpoint as it was configured
+func (c *Config) inheritReferences(before *EndpointConfig, i int) {
+	for _, reference := range c.references {
+		if len(reference.Path) < 3 || reference.Path[0] != "templates" || reference.Path[1] != before.Template {
+			continue
+		}
+		setting := reference.Path[2:]
+		switch setting[0] {
+		case "method":
+			if before.Method != "" {
+				continue
+			}
+		case "probe_type":
+			if before.ProbeType != "" {
+				continue
+			}
+		case "assertions":
+			if before.Assertions != nil {
+				continue
+			}
+		case "labels":
+			if len(setting) < 2 {
+				continue
+			}
+			if _, own := before.Labels[setting[1]]; own {
+				continue
+			}
+		default:
+			continue
+		}
+		path := append([]string{"endpoints", strconv.Itoa(i)}, setting...)
+		c.references = append(c.references, models.Reference{Path: path, Raw: reference.Raw})
+	}
+}
+
+// inherit fills the settings the endpoint leaves unset from a template
+func (ec *EndpointConfig) inherit(t *EndpointTemplate) {
+	if ec.Method == "" {
+		ec.Method = t.Method
+	}
+	if ec.Interval == 0 {
+		ec.Interval = t.Interval
+	}
+	if ec.Timeout == 0 {
+		ec.Timeout = t.Timeout
+	}
+	if ec.ProbeType == "" {
+		ec.ProbeType = t.ProbeType
+	}
+	if ec.Assertions == nil {
+		ec.Assertions = t.Assertions
+	}
+
+	if len(t.Labels) > 0 {
+		labels := make(map[string]string, len(t.Labels)+len(ec.Labels))
+		for name, value := range t.Labels {
+			labels[name] = value
+		}
+		for name, value := range ec.Labels {
+			labels[name] = value
+		}
+		ec.Labels = labels
+	}
+}
+
+// redactedValue replaces credentials in the effective configuration
+const redactedValue = "<redacted>"
+
+// credentialPaths are the settings redacted from the effective configuration,
+// where * matches any list index or map key
+var credentialPaths = [][]string{
+	{"endpoints", "*", "headers", "*"},
+	{"endpoints", "*", "auth"},
+	{"notifications", "notifiers", "*", "webhook", "headers", "*"},
+	{"notifications", "notifiers", "*", "webhook", "secret"},
+	{"notifications", "notifiers", "*", "email", "username"},
+	{"notifications", "notifiers", "*", "email", "password"},
+	{"notifications", "notifiers", "*", "pagerduty", "routing_key"},
+	{"notifications", "notifiers", "*", "opsgenie", "api_key"},
+	{"notifications", "notifiers", "*", "alertmanager", "headers", "*"},
+}
+
+// Effective returns the configuration as it is applied, with files merged,
+// references expanded, templates resolved and defaults filled in, ready to be
+// encoded as JSON. Strings that contained ${...} references are shown as
+// written, so that the values they expand to are not disclosed, and request
+// headers, credentials and chat webhook URLs are redacted.
+func (c *Config) Effective() (interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %v", err)
+	}
+
+	var effective interface{}
+	if err := json.Unmarshal(data, &effective); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
+	}
+	models.ApplyReferences(effective, c.references)
+
+	for _, path := range credentialPaths {
+		redact(effective, path)
+	}
+	// Chat webhook URLs carry the token that allows posting to the channel
+	for i, notifier := range c.Notifications.Notifiers {
+		switch notifier.Type {
+		case "slack", "teams", "mattermost":
+			redact(effective, []string{"notifications", "notifiers", strconv.Itoa(i), "url"})
+		}
+	}
+	return effective, nil
+}
+
+// redact replaces the values matching path in a decoded JSON value
+func redact(value interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if len(path) == 1 {
+				v[key] = redactedValue
+			} else {
+				redact(child, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if path[0] != "*" && path[0] != strconv.Itoa(i) {
+				continue
+			}
+			if len(path) == 1 {
+				v[i] = redactedValue
+			} else {
+				redact(child, path[1:])
+			}
+		}
+	}
+}
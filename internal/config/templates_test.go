@@ -0,0 +1,67 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": `server:
+  port: "8080"
+templates:
+  internal:
+    method: HEAD
+    interval: 60
+    timeout: 3
+    labels:
+      team: platform
+      env: prod
+endpoints:
+  - name: orders
+    url: http://orders.internal/healthz
+    template: internal
+    interval: 15
+    labels:
+      team: shop
+  - name: search
+    url: http://search.internal/healthz
+`,
+	})
+	config, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	orders, search := config.Endpoints[0], config.Endpoints[1]
+	if orders.Method != "HEAD" || orders.Interval != 15 || orders.Timeout != 3 {
+		t.Errorf("orders = %+v, want method and timeout from the template and its own interval", orders)
+	}
+	if orders.Labels["team"] != "shop" || orders.Labels["env"] != "prod" {
+		t.Errorf("orders labels = %v, want the template's merged under its own", orders.Labels)
+	}
+	if search.Method != "GET" || search.Labels["env"] != "" {
+		t.Errorf("search = %+v, want defaults without the template", search)
+	}
+
+	effective, err := config.Effective()
+	if err != nil {
+		t.Fatalf("Effective() error = %v", err)
+	}
+	endpoints := effective.(map[string]interface{})["endpoints"].([]interface{})
+	first := endpoints[0].(map[string]interface{})
+	if first["method"] != "HEAD" || first["labels"].(map[string]interface{})["env"] != "prod" {
+		t.Errorf("effective endpoint = %v, want the template applied", first)
+	}
+}
+
+func TestUnknownTemplate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "server:\n  port: \"8080\"\nendpoints:\n  - name: orders\n    url: http://orders.internal/healthz\n    template: missing\n",
+	})
+	_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err == nil || !strings.Contains(err.Error(), `config.yaml:4: endpoint 0 validation failed: unknown template "missing"`) {
+		t.Fatalf("LoadConfig() error = %v, want an unknown template error", err)
+	}
+}
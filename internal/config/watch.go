@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce collects the burst of events a single update causes
+const watchDebounce = 500 * time.Millisecond
+
+// Watcher reports changes to a configuration file, directory or glob. It
+// watches directories rather than the files themselves, so editors that
+// replace a file and Kubernetes ConfigMap updates, which swap a symlink to a
+// new directory, are detected as well as plain writes.
+type Watcher struct {
+	path    string
+	watcher *fsnotify.Watcher
+	dirs    map[string]bool
+	digest  [sha256.Size]byte
+}
+
+// NewWatcher starts watching the configuration at path
+func NewWatcher(path string) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %v", err)
+	}
+
+	w := &Watcher{path: path, watcher: watcher, dirs: make(map[string]bool)}
+	if err := w.watchDirs(); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	w.digest, _ = w.read()
+	return w, nil
+}
+
+// watchDirs watches the directories the configured path, its files and the
+// files they include are in, including the directories of symlink targets
+func (w *Watcher) watchDirs() error {
+	var dirs []string
+	if info, err := os.Stat(w.path); err == nil && info.IsDir() {
+		dirs = append(dirs, w.path)
+	} else if dir := filepath.Dir(w.path); !isPattern(dir) {
+		dirs = append(dirs, dir)
+	}
+	files, _ := configFiles(w.path)
+	for _, file := range files {
+		dirs = append(dirs, filepath.Dir(file))
+		if target, err := filepath.EvalSymlinks(file); err == nil {
+			dirs = append(dirs, filepath.Dir(target))
+		}
+	}
+
+	for _, dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %v", dir, err)
+		}
+		w.dirs[dir] = true
+	}
+	return nil
+}
+
+// relevant reports whether a changed file may be part of the configuration.
+// Other files in the same directories, such as a database written on every
+// check, would otherwise keep postponing the reload.
+func (w *Watcher) relevant(name string) bool {
+	base := filepath.Base(name)
+	// ConfigMap mounts update through hidden ..data entries
+	return base == filepath.Base(w.path) || isConfigFile(base) || strings.HasPrefix(base, "..")
+}
+
+// read returns the digest of the names and content of the configuration
+// files, including the included ones
+func (w *Watcher) read() ([sha256.Size]byte, error) {
+	files, err := configFiles(w.path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", file, len(data))
+		hash.Write(data)
+	}
+
+	var digest [sha256.Size]byte
+	copy(digest[:], hash.Sum(nil))
+	return digest, nil
+}
+
+// Run calls changed whenever the content of the files changes, until the
+// context is cancelled
+func (w *Watcher) Run(ctx context.Context, changed func()) {
+	defer w.watcher.Close()
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.relevant(event.Name) {
+				settle = time.After(watchDebounce)
+			}
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Config watcher error: %v", err)
+
+		case <-settle:
+			settle = nil
+			if err := w.watchDirs(); err != nil {
+				log.Printf("Config watcher error: %v", err)
+			}
+			digest, err := w.read()
+			if err != nil {
+				// The file is briefly missing while it is being replaced
+				continue
+			}
+			if digest != w.digest {
+				w.digest = digest
+				changed()
+			}
+		}
+	}
+}
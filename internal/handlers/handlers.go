@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"health-caretaker/internal/alerting"
+	"health-caretaker/internal/maintenance"
 	"health-caretaker/internal/models"
 	"health-caretaker/internal/monitor"
+	"health-caretaker/internal/reports"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -18,6 +24,16 @@ type Handler struct {
 	metricsCollector interface {
 		GetMetrics() string
 	}
+	wsHistory   int // Check results sent per endpoint when a WebSocket client connects
+	silences    *alerting.Silences
+	maintenance *maintenance.Windows
+	config      func() (interface{}, error) // Effective configuration
+}
+
+// endpointWithHistory is an endpoint together with its recent check results
+type endpointWithHistory struct {
+	*models.Endpoint
+	History []models.CheckResult `json:"history"`
 }
 
 // NewHandler creates a new handler instance
@@ -25,6 +41,47 @@ func NewHandler(m *monitor.Monitor, metrics interface{ GetMetrics() string }) *H
 	return &Handler{
 		monitor:          m,
 		metricsCollector: metrics,
+		wsHistory:        30,
+	}
+}
+
+// SetWebSocketHistory sets how many recent results are sent per endpoint to new WebSocket clients
+func (h *Handler) SetWebSocketHistory(n int) {
+	h.wsHistory = n
+}
+
+// SetSilences sets the silence registry served by the silences API
+func (h *Handler) SetSilences(silences *alerting.Silences) {
+	h.silences = silences
+}
+
+// SetMaintenance sets the maintenance windows served by the maintenance API
+func (h *Handler) SetMaintenance(windows *maintenance.Windows) {
+	h.maintenance = windows
+}
+
+// SetConfigSource sets the function returning the configuration served by
+// the effective configuration API
+func (h *Handler) SetConfigSource(source func() (interface{}, error)) {
+	h.config = source
+}
+
+// HandleEffectiveConfig serves the configuration currently in effect
+func (h *Handler) HandleEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	if h.config == nil {
+		http.Error(w, "Configuration not available", http.StatusNotFound)
+		return
+	}
+
+	effective, err := h.config()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(effective); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
 	}
 }
 
@@ -39,20 +96,48 @@ func (h *Handler) HandleAPIEndpoints(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case "GET":
-		endpoints := h.monitor.GetEndpoints()
+		endpoints := redacted(h.monitor.GetEndpoints())
 		if err := json.NewEncoder(w).Encode(endpoints); err != nil {
 			http.Error(w, "encode error", http.StatusInternalServerError)
 			return
 		}
 
 	case "POST":
-		var endpoint models.Endpoint
-		if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		// Headers, body files and credentials are only taken from the config
+		// file, so that API callers cannot make the server read local files
+		// or environment variables and send them elsewhere
+		var request struct {
+			models.Endpoint
+			Headers  json.RawMessage `json:"headers"`
+			BodyFile json.RawMessage `json:"body_file"`
+			Auth     json.RawMessage `json:"auth"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 			http.Error(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
+		if request.Headers != nil || request.BodyFile != nil || request.Auth != nil {
+			http.Error(w, "headers, body_file and auth can only be set in the config file", http.StatusBadRequest)
+			return
+		}
+		endpoint := request.Endpoint
 
-		h.monitor.AddEndpoint(&endpoint)
+		if endpoint.ID != "" {
+			if err := models.ValidateEndpointID(endpoint.ID); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		endpoint.Source = models.SourceAPI
+		if err := h.monitor.AddEndpoint(&endpoint); err != nil {
+			if err == monitor.ErrDuplicateID {
+				http.Error(w, fmt.Sprintf("Endpoint ID %q is already in use", endpoint.ID), http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		w.WriteHeader(http.StatusCreated)
 		if err := json.NewEncoder(w).Encode(endpoint); err != nil {
 			http.Error(w, "encode error", http.StatusInternalServerError)
@@ -67,23 +152,391 @@ func (h *Handler) HandleAPIEndpoints(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// redacted returns the endpoints as they may be shown to clients
+func redacted(endpoints []*models.Endpoint) []*models.Endpoint {
+	result := make([]*models.Endpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		result[i] = endpoint.Redacted()
+	}
+	return result
+}
+
 // HandleCheckEndpoint manually triggers a check for a specific endpoint
 func (h *Handler) HandleCheckEndpoint(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	endpoint, exists := h.monitor.GetEndpoint(id)
+	if !h.monitor.TriggerCheck(id) {
+		http.Error(w, "Endpoint not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleAcknowledge acknowledges the open incident of an endpoint so that
+// repeat reminders stop. The body may name who acknowledged it.
+func (h *Handler) HandleAcknowledge(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, exists := h.monitor.GetEndpoint(id); !exists {
+		http.Error(w, "Endpoint not found", http.StatusNotFound)
+		return
+	}
+
+	var request struct {
+		By string `json:"by"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if request.By == "" {
+		request.By = "anonymous"
+	}
+
+	incident, open := h.monitor.AcknowledgeIncident(id, request.By)
+	if !open {
+		http.Error(w, "Endpoint has no open incident", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(incident); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleSilences lists (GET, with all=true to include expired ones), creates
+// (POST) and expires (DELETE) notification silences
+func (h *Handler) HandleSilences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		silences := h.silences.List(r.URL.Query().Get("all") == "true")
+		if err := json.NewEncoder(w).Encode(silences); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+
+	case "POST":
+		var request struct {
+			models.Silence
+			Duration string `json:"duration"` // Alternative to endsAt, e.g. "2h"
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if request.Duration != "" {
+			duration, err := time.ParseDuration(request.Duration)
+			if err != nil {
+				http.Error(w, "Invalid duration", http.StatusBadRequest)
+				return
+			}
+			start := request.StartsAt
+			if start.IsZero() {
+				start = time.Now()
+			}
+			request.StartsAt = start
+			request.EndsAt = start.Add(duration)
+		}
+
+		silence, err := h.silences.Add(request.Silence)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(silence); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+
+	case "DELETE":
+		if _, exists := h.silences.Expire(mux.Vars(r)["id"]); !exists {
+			http.Error(w, "Silence not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleMaintenance lists (GET, with all=true to include ended windows),
+// creates (POST) and deletes (DELETE) maintenance windows
+func (h *Handler) HandleMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		windows := h.maintenance.List(r.URL.Query().Get("all") == "true")
+		if err := json.NewEncoder(w).Encode(windows); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+
+	case "POST":
+		var window models.MaintenanceWindow
+		if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		created, err := h.maintenance.Add(window)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(created); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+
+	case "DELETE":
+		switch err := h.maintenance.Delete(mux.Vars(r)["id"]); err {
+		case nil:
+			w.WriteHeader(http.StatusNoContent)
+		case maintenance.ErrNotFound:
+			http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusConflict)
+		}
+	}
+}
+
+// HandleIncidents lists incidents, most recently started first. Supports
+// state (open, acknowledged, resolved), endpoint, label (name=value, may be
+// repeated), from/to on the start time and limit query parameters.
+func (h *Handler) HandleIncidents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	state := query.Get("state")
+	switch state {
+	case "", models.IncidentOpen, models.IncidentAcknowledged, models.IncidentResolved:
+	default:
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+		return
+	}
+
+	labels := make(map[string]string)
+	for _, selector := range query["label"] {
+		name, value, ok := strings.Cut(selector, "=")
+		if !ok || name == "" {
+			http.Error(w, "Invalid label parameter, expected name=value", http.StatusBadRequest)
+			return
+		}
+		labels[name] = value
+	}
+
+	from, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(query.Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	incidents, err := h.monitor.GetIncidents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	endpointID := query.Get("endpoint")
+	matched := []*models.Incident{}
+	for _, incident := range incidents {
+		if limit > 0 && len(matched) == limit {
+			break
+		}
+		if endpointID != "" && incident.EndpointID != endpointID {
+			continue
+		}
+		if !matchesState(incident, state) || !matchesLabels(incident.Labels, labels) {
+			continue
+		}
+		if (!from.IsZero() && incident.StartedAt.Before(from)) || (!to.IsZero() && incident.StartedAt.After(to)) {
+			continue
+		}
+		matched = append(matched, incident)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(matched); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// matchesState reports whether an incident is in the requested state; open
+// includes acknowledged incidents and an empty state matches every incident
+func matchesState(incident *models.Incident, state string) bool {
+	switch state {
+	case "":
+		return true
+	case models.IncidentOpen:
+		return incident.IsOpen()
+	default:
+		return incident.State() == state
+	}
+}
+
+// matchesLabels reports whether all selected labels have the given values
+func matchesLabels(labels, selected map[string]string) bool {
+	for name, value := range selected {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// HandleIncident returns a single incident with its notes and timeline
+func (h *Handler) HandleIncident(w http.ResponseWriter, r *http.Request) {
+	incident, exists := h.monitor.GetIncident(mux.Vars(r)["id"])
 	if !exists {
+		http.Error(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(incident); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleIncidentNote adds a note to an incident and returns the updated incident
+func (h *Handler) HandleIncidentNote(w http.ResponseWriter, r *http.Request) {
+	var note models.IncidentNote
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(note.Text) == "" {
+		http.Error(w, "Note text is required", http.StatusBadRequest)
+		return
+	}
+
+	incident, exists := h.monitor.AddIncidentNote(mux.Vars(r)["id"], note.Author, note.Text)
+	if !exists {
+		http.Error(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(incident); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleEndpointHistory returns the check history of an endpoint.
+// Supports from/to (RFC 3339 or unix seconds) and limit query parameters.
+func (h *Handler) HandleEndpointHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, exists := h.monitor.GetEndpoint(id); !exists {
 		http.Error(w, "Endpoint not found", http.StatusNotFound)
 		return
 	}
 
-	go func() {
-		h.monitor.CheckEndpoint(endpoint)
-		h.monitor.BroadcastUpdate(endpoint)
-	}()
+	query := r.URL.Query()
+	from, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(query.Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+		return
+	}
 
-	w.WriteHeader(http.StatusOK)
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.monitor.GetHistory(id, from, to, limit)); err != nil {
+		http.Error(w, "encode error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// HandleUptimeReport returns availability per endpoint and, with group_by,
+// per label group. The period is a rolling window (window=24h|7d|30d), a
+// calendar month (month=YYYY-MM) or a custom range (from, to). format=csv
+// returns CSV instead of JSON.
+func (h *Handler) HandleUptimeReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	period, err := reports.ParseRange(query.Get("window"), query.Get("month"), query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	endpoints := h.monitor.GetEndpoints()
+	if id := query.Get("id"); id != "" {
+		endpoint, exists := h.monitor.GetEndpoint(id)
+		if !exists {
+			http.Error(w, "Endpoint not found", http.StatusNotFound)
+			return
+		}
+		endpoints = []*models.Endpoint{endpoint}
+	}
+
+	report, err := reports.Uptime(endpoints, h.monitor.Results, period, query.Get("group_by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch query.Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.Filename("csv")))
+		if err := report.WriteCSV(w); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Unsupported format", http.StatusBadRequest)
+	}
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or unix seconds; empty means unbounded
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
 
 // HandleWebSocket handles WebSocket connections
@@ -99,10 +552,13 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	h.monitor.AddClient(conn)
 	defer h.monitor.RemoveClient(conn)
 
-	// Send initial data
+	// Send initial data, including recent results for sparklines
 	endpoints := h.monitor.GetEndpoints()
 	for _, endpoint := range endpoints {
-		message, err := json.Marshal(endpoint)
+		message, err := json.Marshal(endpointWithHistory{
+			Endpoint: endpoint.Redacted(),
+			History:  h.monitor.GetHistory(endpoint.ID, time.Time{}, time.Time{}, h.wsHistory),
+		})
 		if err != nil {
 			continue
 		}
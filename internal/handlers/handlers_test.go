@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/monitor"
+)
+
+func postEndpoint(h *Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/endpoints", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.HandleAPIEndpoints(rec, req)
+	return rec
+}
+
+func TestAddEndpointIDs(t *testing.T) {
+	m := monitor.NewMonitor()
+	h := NewHandler(m, nil)
+
+	configured := &models.Endpoint{Name: "Orders API", URL: "https://orders.internal/healthz", Source: models.SourceConfig}
+	if err := m.AddEndpoint(configured); err != nil {
+		t.Fatalf("AddEndpoint: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		id     string
+	}{
+		{
+			name:   "derived ID",
+			body:   `{"name": "Billing", "url": "https://billing.internal/healthz"}`,
+			status: http.StatusCreated,
+			id:     models.EndpointID("Billing", "https://billing.internal/healthz"),
+		},
+		{
+			name:   "explicit ID",
+			body:   `{"id": "search", "name": "Search", "url": "https://search.internal/healthz"}`,
+			status: http.StatusCreated,
+			id:     "search",
+		},
+		{
+			name:   "explicit ID in use",
+			body:   `{"id": "search", "name": "Search v2", "url": "https://search.internal/v2/healthz"}`,
+			status: http.StatusConflict,
+		},
+		{
+			name:   "derived ID of a configured endpoint",
+			body:   `{"name": "Orders API", "url": "https://orders.internal/healthz"}`,
+			status: http.StatusConflict,
+		},
+		{
+			name:   "invalid ID",
+			body:   `{"id": "search/v2", "name": "Search", "url": "https://search.internal/healthz"}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := postEndpoint(h, test.body)
+			if rec.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, test.status, rec.Body.String())
+			}
+			if test.id == "" {
+				return
+			}
+			var endpoint models.Endpoint
+			if err := json.NewDecoder(rec.Body).Decode(&endpoint); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if endpoint.ID != test.id {
+				t.Errorf("id = %q, want %q", endpoint.ID, test.id)
+			}
+			if _, exists := m.GetEndpoint(test.id); !exists {
+				t.Errorf("endpoint %q is not monitored", test.id)
+			}
+		})
+	}
+
+	if len(m.GetEndpoints()) != 3 {
+		t.Errorf("monitoring %d endpoints, want 3", len(m.GetEndpoints()))
+	}
+}
@@ -0,0 +1,170 @@
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	"health-caretaker/internal/models"
+
+	"github.com/robfig/cron/v3"
+	"github.com/teambition/rrule-go"
+)
+
+// schedule computes the occurrences of a maintenance window
+type schedule interface {
+	// current returns the occurrence in effect at t
+	current(t time.Time) (start, end time.Time, ok bool)
+	// next returns the first occurrence starting after t
+	next(t time.Time) (start, end time.Time, ok bool)
+}
+
+// compile parses the window's schedule. Windows must have been validated.
+func compile(window *models.MaintenanceWindow, now time.Time) (schedule, error) {
+	var duration time.Duration
+	if window.Duration != "" {
+		duration, _ = time.ParseDuration(window.Duration)
+	}
+
+	location := time.UTC
+	if window.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(window.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %v", window.Timezone, err)
+		}
+	}
+
+	switch {
+	case window.Cron != "":
+		spec, err := cron.ParseStandard(window.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", window.Cron, err)
+		}
+		return &cronSchedule{spec: spec, duration: duration, location: location}, nil
+
+	case window.RRule != "":
+		option, err := rrule.StrToROptionInLocation(window.RRule, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule %q: %v", window.RRule, err)
+		}
+		if option.Dtstart.IsZero() {
+			// Without DTSTART, occurrences are counted from starts_at or
+			// from the fixed rule epoch
+			if window.StartsAt != nil {
+				option.Dtstart = window.StartsAt.In(location)
+			} else {
+				option.Dtstart = ruleAnchor(option, now.In(location))
+			}
+		}
+		rule, err := rrule.NewRRule(*option)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule %q: %v", window.RRule, err)
+		}
+		return &ruleSchedule{rule: rule, duration: duration}, nil
+
+	default:
+		start := *window.StartsAt
+		end := start.Add(duration)
+		if window.EndsAt != nil {
+			end = *window.EndsAt
+		}
+		return &oneOff{start: start, end: end}, nil
+	}
+}
+
+// ruleAnchor returns the DTSTART for a rule that sets none: the start of the
+// rule's period before the current one, counting periods of INTERVAL times
+// the frequency from midnight on Monday, 3 January 2000 in the rule's time
+// zone. Occurrences then keep the same phase across restarts and reloads,
+// while only a few periods have to be iterated to find the current one.
+func ruleAnchor(option *rrule.ROption, now time.Time) time.Time {
+	location := now.Location()
+	epoch := time.Date(2000, time.January, 3, 0, 0, 0, 0, location)
+	interval := option.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch option.Freq {
+	case rrule.YEARLY:
+		periods := (now.Year()-epoch.Year())/interval - 1
+		return time.Date(epoch.Year()+periods*interval, time.January, 1, 0, 0, 0, 0, location)
+	case rrule.MONTHLY:
+		months := (now.Year()-epoch.Year())*12 + int(now.Month()-epoch.Month())
+		periods := months/interval - 1
+		return time.Date(epoch.Year(), epoch.Month()+time.Month(periods*interval), 1, 0, 0, 0, 0, location)
+	case rrule.WEEKLY, rrule.DAILY:
+		// Whole days between the calendar dates, unaffected by DST changes
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		days := int(today.Sub(time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		step := interval
+		if option.Freq == rrule.WEEKLY {
+			step *= 7
+		}
+		periods := days/step - 1
+		return epoch.AddDate(0, 0, periods*step)
+	default:
+		unit := time.Hour
+		switch option.Freq {
+		case rrule.MINUTELY:
+			unit = time.Minute
+		case rrule.SECONDLY:
+			unit = time.Second
+		}
+		step := unit * time.Duration(interval)
+		periods := now.Sub(epoch)/step - 1
+		return epoch.Add(periods * step)
+	}
+}
+
+// oneOff is a single window between two times
+type oneOff struct {
+	start, end time.Time
+}
+
+func (o *oneOff) current(t time.Time) (time.Time, time.Time, bool) {
+	return o.start, o.end, !t.Before(o.start) && t.Before(o.end)
+}
+
+func (o *oneOff) next(t time.Time) (time.Time, time.Time, bool) {
+	return o.start, o.end, o.start.After(t)
+}
+
+// cronSchedule starts an occurrence at every time matching a cron expression
+type cronSchedule struct {
+	spec     cron.Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+func (c *cronSchedule) current(t time.Time) (time.Time, time.Time, bool) {
+	// The only occurrence that can cover t starts within the preceding duration
+	start := c.spec.Next(t.In(c.location).Add(-c.duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(c.duration), true
+}
+
+func (c *cronSchedule) next(t time.Time) (time.Time, time.Time, bool) {
+	start := c.spec.Next(t.In(c.location))
+	return start, start.Add(c.duration), !start.IsZero()
+}
+
+// ruleSchedule starts an occurrence at every recurrence of an RRULE
+type ruleSchedule struct {
+	rule     *rrule.RRule
+	duration time.Duration
+}
+
+func (r *ruleSchedule) current(t time.Time) (time.Time, time.Time, bool) {
+	start := r.rule.Before(t, true)
+	if start.IsZero() || !t.Before(start.Add(r.duration)) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(r.duration), true
+}
+
+func (r *ruleSchedule) next(t time.Time) (time.Time, time.Time, bool) {
+	start := r.rule.After(t, false)
+	return start, start.Add(r.duration), !start.IsZero()
+}
@@ -0,0 +1,178 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+
+	"github.com/teambition/rrule-go"
+)
+
+// location loads a time zone or fails the test
+func location(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestRuleAnchor(t *testing.T) {
+	berlin := location(t, "Europe/Berlin")
+	tests := []struct {
+		name string
+		rule string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			name: "daily",
+			rule: "FREQ=DAILY",
+			now:  time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly from Monday",
+			rule: "FREQ=WEEKLY",
+			now:  time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC), // Sunday
+			want: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every other week",
+			rule: "FREQ=WEEKLY;INTERVAL=2",
+			now:  time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every other week, a week later",
+			rule: "FREQ=WEEKLY;INTERVAL=2",
+			now:  time.Date(2026, 3, 22, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "quarterly",
+			rule: "FREQ=MONTHLY;INTERVAL=3",
+			now:  time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "yearly",
+			rule: "FREQ=YEARLY",
+			now:  time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every six hours",
+			rule: "FREQ=HOURLY;INTERVAL=6",
+			now:  time.Date(2026, 3, 15, 10, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "daily on the day DST starts",
+			rule: "FREQ=DAILY",
+			now:  time.Date(2026, 3, 29, 12, 0, 0, 0, berlin),
+			want: time.Date(2026, 3, 28, 0, 0, 0, 0, berlin),
+		},
+		{
+			name: "daily the day after DST starts",
+			rule: "FREQ=DAILY",
+			now:  time.Date(2026, 3, 30, 0, 30, 0, 0, berlin),
+			want: time.Date(2026, 3, 29, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			option, err := rrule.StrToROptionInLocation(tt.rule, tt.now.Location())
+			if err != nil {
+				t.Fatalf("invalid rule: %v", err)
+			}
+			if got := ruleAnchor(option, tt.now); !got.Equal(tt.want) {
+				t.Errorf("ruleAnchor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// occurrence is the expected current occurrence at a time, if any
+type occurrence struct {
+	at         time.Time
+	start, end time.Time // Zero when no occurrence is in effect
+}
+
+// checkCurrent compiles a window at now and checks its current occurrences
+func checkCurrent(t *testing.T, window models.MaintenanceWindow, now time.Time, tests []occurrence) {
+	t.Helper()
+	if err := Validate(&window); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	s, err := compile(&window, now)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	for _, tt := range tests {
+		start, end, ok := s.current(tt.at)
+		if ok != !tt.start.IsZero() || (ok && (!start.Equal(tt.start) || !end.Equal(tt.end))) {
+			t.Errorf("current(%v) = %v, %v, %v; want %v, %v", tt.at, start, end, ok, tt.start, tt.end)
+		}
+	}
+}
+
+func TestCronCurrent(t *testing.T) {
+	berlin := location(t, "Europe/Berlin")
+	window := models.MaintenanceWindow{
+		EndpointIDs: []string{"orders"},
+		Cron:        "0 1 * * *",
+		Duration:    "3h",
+		Timezone:    "Europe/Berlin",
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, berlin) }
+
+	checkCurrent(t, window, at(1, 0, 0), []occurrence{
+		{at: at(10, 0, 59)},
+		{at: at(10, 1, 0), start: at(10, 1, 0), end: at(10, 4, 0)},
+		{at: at(10, 3, 59), start: at(10, 1, 0), end: at(10, 4, 0)},
+		{at: at(10, 4, 0)},
+		// The same instant given in UTC
+		{at: at(10, 2, 30).UTC(), start: at(10, 1, 0), end: at(10, 4, 0)},
+		// Clocks go forward at 02:00 on 29 March, so the three hours from
+		// 01:00 CET end at 05:00 CEST
+		{at: at(29, 4, 30), start: at(29, 1, 0), end: at(29, 5, 0)},
+		{at: at(29, 5, 0)},
+	})
+}
+
+func TestRuleCurrent(t *testing.T) {
+	newYork := location(t, "America/New_York")
+	window := models.MaintenanceWindow{
+		EndpointIDs: []string{"orders"},
+		RRule:       "FREQ=WEEKLY;BYDAY=SA;BYHOUR=22;BYMINUTE=0;BYSECOND=0",
+		Duration:    "4h",
+		Timezone:    "America/New_York",
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, newYork) }
+
+	checkCurrent(t, window, at(1, 12, 0), []occurrence{
+		{at: at(7, 21, 59)},
+		{at: at(7, 22, 0), start: at(7, 22, 0), end: at(7, 22, 0).Add(4 * time.Hour)},
+		// Clocks go forward at 02:00 on Sunday 8 March: four hours from
+		// 22:00 EST end at 03:00 EDT
+		{at: at(8, 1, 59), start: at(7, 22, 0), end: at(8, 3, 0)},
+		{at: at(8, 3, 0)},
+		// The next week starts at 22:00 EDT
+		{at: at(14, 23, 0), start: at(14, 22, 0), end: at(15, 2, 0)},
+		{at: at(15, 2, 0)},
+	})
+
+	// Without DTSTART, occurrences keep their phase whenever the rule is compiled
+	biweekly := window
+	biweekly.RRule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA;BYHOUR=22;BYMINUTE=0;BYSECOND=0"
+	for _, now := range []time.Time{at(1, 12, 0), at(8, 12, 0), at(15, 12, 0)} {
+		checkCurrent(t, biweekly, now, []occurrence{
+			{at: at(14, 23, 0), start: at(14, 22, 0), end: at(15, 2, 0)},
+			{at: at(21, 23, 0)},
+		})
+	}
+}
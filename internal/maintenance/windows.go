@@ -0,0 +1,286 @@
+// Package maintenance schedules maintenance windows, during which endpoints
+// are shown as in maintenance, are not alerted on and do not count against
+// their uptime
+package maintenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/storage"
+)
+
+var (
+	// ErrNotFound is returned for an unknown maintenance window
+	ErrNotFound = errors.New("maintenance window not found")
+	// ErrConfigured is returned when deleting a window defined in the configuration
+	ErrConfigured = errors.New("maintenance window is defined in the configuration")
+)
+
+// Windows holds the maintenance windows from the configuration and those
+// created through the API, persisting the latter when a store is configured
+type Windows struct {
+	mutex sync.RWMutex
+	items map[string]*entry
+	store storage.Store
+}
+
+// entry is a window together with its compiled schedule
+type entry struct {
+	window   *models.MaintenanceWindow
+	schedule schedule
+}
+
+// Occurrence is a single period of a maintenance window
+type Occurrence struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Status is a window with its current or next occurrence
+type Status struct {
+	*models.MaintenanceWindow
+	Active  bool        `json:"active"`
+	Current *Occurrence `json:"current,omitempty"` // Occurrence in effect now
+	Next    *Occurrence `json:"next,omitempty"`    // First occurrence starting later
+}
+
+// Validate checks a window and parses its recurrence
+func Validate(window *models.MaintenanceWindow) error {
+	if err := window.Validate(); err != nil {
+		return err
+	}
+	_, err := compile(window, time.Now())
+	return err
+}
+
+// New creates the registry from the configured windows, loading the windows
+// previously created through the API from the store
+func New(configured []models.MaintenanceWindow, store storage.Store) (*Windows, error) {
+	w := &Windows{items: make(map[string]*entry), store: store}
+
+	if store != nil {
+		stored, err := store.MaintenanceWindows()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load maintenance windows: %v", err)
+		}
+		for _, window := range stored {
+			schedule, err := compile(window, time.Now())
+			if err != nil {
+				log.Printf("Skipping stored maintenance window %s: %v", window.ID, err)
+				continue
+			}
+			w.items[window.ID] = &entry{window: window, schedule: schedule}
+		}
+	}
+
+	if err := w.SetConfigured(configured); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// SetConfigured replaces the windows defined in the configuration, keeping
+// those created through the API. Nothing changes if a window is invalid.
+func (w *Windows) SetConfigured(configured []models.MaintenanceWindow) error {
+	now := time.Now()
+	items := make(map[string]*entry, len(configured))
+	for i := range configured {
+		window := configured[i]
+		if window.ID == "" {
+			window.ID = configuredID(window)
+		}
+		window.Source = models.SourceConfig
+		if err := window.Validate(); err != nil {
+			return fmt.Errorf("maintenance window %s: %v", window.ID, err)
+		}
+		schedule, err := compile(&window, now)
+		if err != nil {
+			return fmt.Errorf("maintenance window %s: %v", window.ID, err)
+		}
+		items[window.ID] = &entry{window: &window, schedule: schedule}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for id, item := range w.items {
+		if item.window.Source == models.SourceConfig {
+			delete(w.items, id)
+		}
+	}
+	for id, item := range items {
+		w.items[id] = item
+	}
+	return nil
+}
+
+// RenameEndpoint points the windows created through the API that cover an
+// endpoint at its new ID. The store is updated together with the endpoint's
+// other records.
+func (w *Windows) RenameEndpoint(oldID, newID string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for _, item := range w.items {
+		if item.window.Source == models.SourceConfig {
+			continue
+		}
+		for i, id := range item.window.EndpointIDs {
+			if id == oldID {
+				ids := append([]string(nil), item.window.EndpointIDs...)
+				ids[i] = newID
+				item.window.EndpointIDs = ids
+				break
+			}
+		}
+	}
+}
+
+// configuredID derives the ID of a configured window that sets none from its
+// definition, so that it does not change when windows are reordered
+func configuredID(window models.MaintenanceWindow) string {
+	window.Source = ""
+	data, err := json.Marshal(window)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "config_" + hex.EncodeToString(sum[:8])
+}
+
+// Add validates and stores a window created through the API
+func (w *Windows) Add(window models.MaintenanceWindow) (*models.MaintenanceWindow, error) {
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	schedule, err := compile(&window, now)
+	if err != nil {
+		return nil, err
+	}
+	if once, ok := schedule.(*oneOff); ok {
+		if !once.end.After(now) {
+			return nil, fmt.Errorf("maintenance window must end in the future")
+		}
+		// Store the end so expired windows can be pruned
+		window.EndsAt = &once.end
+		window.Duration = ""
+	}
+
+	window.ID = fmt.Sprintf("maintenance_%d", now.UnixNano())
+	window.Source = models.SourceAPI
+
+	w.mutex.Lock()
+	w.items[window.ID] = &entry{window: &window, schedule: schedule}
+	w.mutex.Unlock()
+
+	if w.store != nil {
+		if err := w.store.SaveMaintenanceWindow(&window); err != nil {
+			log.Printf("Error saving maintenance window %s: %v", window.ID, err)
+		}
+	}
+	copied := window
+	return &copied, nil
+}
+
+// Delete removes a window created through the API
+func (w *Windows) Delete(id string) error {
+	w.mutex.Lock()
+	item, exists := w.items[id]
+	if !exists {
+		w.mutex.Unlock()
+		return ErrNotFound
+	}
+	if item.window.Source == models.SourceConfig {
+		w.mutex.Unlock()
+		return ErrConfigured
+	}
+	delete(w.items, id)
+	w.mutex.Unlock()
+
+	if w.store != nil {
+		if err := w.store.DeleteMaintenanceWindow(id); err != nil {
+			log.Printf("Error deleting maintenance window %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// List returns the windows with their current or next occurrence, active
+// ones first and then by next start. One-off windows that have ended are only
+// included when requested.
+func (w *Windows) List(includeEnded bool) []*Status {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	now := time.Now()
+	statuses := make([]*Status, 0, len(w.items))
+	for _, item := range w.items {
+		copied := *item.window
+		status := &Status{MaintenanceWindow: &copied}
+		if start, end, ok := item.schedule.current(now); ok {
+			status.Active = true
+			status.Current = &Occurrence{Start: start, End: end}
+		}
+		if start, end, ok := item.schedule.next(now); ok {
+			status.Next = &Occurrence{Start: start, End: end}
+		}
+		if !includeEnded && !status.Active && status.Next == nil {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.Active != b.Active {
+			return a.Active
+		}
+		if (a.Next == nil) != (b.Next == nil) {
+			return a.Next != nil
+		}
+		if a.Next != nil && !a.Next.Start.Equal(b.Next.Start) {
+			return a.Next.Start.Before(b.Next.Start)
+		}
+		return a.ID < b.ID
+	})
+	return statuses
+}
+
+// Active returns the window covering the endpoint at the given time, or nil;
+// of several overlapping windows the one with the lowest ID is returned.
+// A nil registry has no windows.
+func (w *Windows) Active(endpointID string, labels map[string]string, at time.Time) *models.MaintenanceWindow {
+	if w == nil {
+		return nil
+	}
+
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	var active *models.MaintenanceWindow
+	for _, item := range w.items {
+		if active != nil && item.window.ID > active.ID {
+			continue
+		}
+		if !item.window.Matches(endpointID, labels) {
+			continue
+		}
+		if _, _, ok := item.schedule.current(at); ok {
+			active = item.window
+		}
+	}
+	if active == nil {
+		return nil
+	}
+	copied := *active
+	return &copied
+}
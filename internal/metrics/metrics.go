@@ -8,18 +8,32 @@ import (
 	"time"
 
 	"health-caretaker/internal/models"
+
+	"google.golang.org/grpc/codes"
 )
 
 // MetricsCollector collects and serves metrics
 type MetricsCollector struct {
 	endpoints map[string]*models.Endpoint
 	mutex     sync.RWMutex
+	poolStats func() models.PoolStats // Source of probe pool utilisation
+	reloads   reloadStats
+}
+
+// reloadStats counts configuration reloads
+type reloadStats struct {
+	successes   int
+	failures    int
+	lastOK      bool
+	lastSuccess time.Time
 }
 
 // NewMetricsCollector creates a new metrics collector
 func NewMetricsCollector() *MetricsCollector {
 	return &MetricsCollector{
 		endpoints: make(map[string]*models.Endpoint),
+		// The configuration loaded at startup counts as the first successful load
+		reloads: reloadStats{lastOK: true, lastSuccess: time.Now()},
 	}
 }
 
@@ -30,6 +44,27 @@ func (mc *MetricsCollector) UpdateEndpoint(endpoint *models.Endpoint) {
 	mc.endpoints[endpoint.ID] = endpoint
 }
 
+// SetPoolStatsFunc sets the source of the probe pool metrics
+func (mc *MetricsCollector) SetPoolStatsFunc(fn func() models.PoolStats) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	mc.poolStats = fn
+}
+
+// RecordReload counts a configuration reload attempt
+func (mc *MetricsCollector) RecordReload(success bool) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	mc.reloads.lastOK = success
+	if success {
+		mc.reloads.successes++
+		mc.reloads.lastSuccess = time.Now()
+	} else {
+		mc.reloads.failures++
+	}
+}
+
 // RemoveEndpoint removes an endpoint from metrics
 func (mc *MetricsCollector) RemoveEndpoint(id string) {
 	mc.mutex.Lock()
@@ -52,8 +87,15 @@ func (mc *MetricsCollector) GetMetrics() string {
 	// Add endpoint metrics
 	for _, endpoint := range mc.endpoints {
 		// Probe success (1 = up, 0 = down) - similar to blackbox exporter
+		// During maintenance the raw result of the latest check is reported
+		status := endpoint.Status
+		inMaintenance := 0
+		if status == "maintenance" {
+			status = endpoint.LastResult
+			inMaintenance = 1
+		}
 		probeSuccess := 0
-		if endpoint.Status == "up" {
+		if status == "up" || status == "degraded" {
 			probeSuccess = 1
 		}
 
@@ -64,6 +106,24 @@ func (mc *MetricsCollector) GetMetrics() string {
 		b.WriteString("# TYPE probe_success gauge\n")
 		b.WriteString(fmt.Sprintf("probe_success{%s} %d\n", labels, probeSuccess))
 
+		// Raw result of the latest check, before failure/success thresholds
+		lastResultSuccess := 0
+		if endpoint.LastResult == "up" || endpoint.LastResult == "degraded" {
+			lastResultSuccess = 1
+		}
+
+		b.WriteString("# HELP probe_last_result_success Displays whether the latest check succeeded, before thresholds\n")
+		b.WriteString("# TYPE probe_last_result_success gauge\n")
+		b.WriteString(fmt.Sprintf("probe_last_result_success{%s} %d\n", labels, lastResultSuccess))
+
+		b.WriteString("# HELP probe_consecutive_failures Number of consecutive failed checks\n")
+		b.WriteString("# TYPE probe_consecutive_failures gauge\n")
+		b.WriteString(fmt.Sprintf("probe_consecutive_failures{%s} %d\n", labels, endpoint.ConsecutiveFailures))
+
+		b.WriteString("# HELP probe_attempts Number of attempts made by the latest check\n")
+		b.WriteString("# TYPE probe_attempts gauge\n")
+		b.WriteString(fmt.Sprintf("probe_attempts{%s} %d\n", labels, len(endpoint.Attempts)))
+
 		// Response time
 		b.WriteString("# HELP probe_duration_seconds Returns how long the probe took to complete in seconds\n")
 		b.WriteString("# TYPE probe_duration_seconds gauge\n")
@@ -74,6 +134,53 @@ func (mc *MetricsCollector) GetMetrics() string {
 		b.WriteString("# TYPE probe_http_status_code gauge\n")
 		b.WriteString(fmt.Sprintf("probe_http_status_code{%s} %d\n", labels, endpoint.StatusCode))
 
+		// TCP connect time
+		if endpoint.Kind() == models.ProbeKindTCP {
+			b.WriteString("# HELP probe_tcp_connect_duration_seconds Time taken to establish the TCP connection in seconds\n")
+			b.WriteString("# TYPE probe_tcp_connect_duration_seconds gauge\n")
+			b.WriteString(fmt.Sprintf("probe_tcp_connect_duration_seconds{%s} %.3f\n", labels, float64(endpoint.ConnectTime)/1000.0))
+		}
+
+		// DNS lookup time and answer count
+		if endpoint.Kind() == models.ProbeKindDNS {
+			b.WriteString("# HELP probe_dns_lookup_time_seconds Returns the time taken for the DNS query in seconds\n")
+			b.WriteString("# TYPE probe_dns_lookup_time_seconds gauge\n")
+			b.WriteString(fmt.Sprintf("probe_dns_lookup_time_seconds{%s} %.3f\n", labels, float64(endpoint.ResponseTime)/1000.0))
+
+			b.WriteString("# HELP probe_dns_answer_rrs Returns the number of answers of the queried type\n")
+			b.WriteString("# TYPE probe_dns_answer_rrs gauge\n")
+			b.WriteString(fmt.Sprintf("probe_dns_answer_rrs{%s} %d\n", labels, len(endpoint.DNSAnswers)))
+		}
+
+		// gRPC health check status
+		if endpoint.Kind() == models.ProbeKindGRPC && endpoint.GRPCCode != "" {
+			b.WriteString("# HELP probe_grpc_status_code Response gRPC status code\n")
+			b.WriteString("# TYPE probe_grpc_status_code gauge\n")
+			b.WriteString(fmt.Sprintf("probe_grpc_status_code{%s} %d\n", labels, grpcCodeValue(endpoint.GRPCCode)))
+
+			if endpoint.GRPCStatus != "" {
+				b.WriteString("# HELP probe_grpc_healthcheck_response Response HealthCheck response\n")
+				b.WriteString("# TYPE probe_grpc_healthcheck_response gauge\n")
+				b.WriteString(fmt.Sprintf("probe_grpc_healthcheck_response{%s, serving_status=\"%s\"} 1\n", labels, mc.escapeLabelValue(endpoint.GRPCStatus)))
+			}
+		}
+
+		// TLS certificate expiry and chain validity
+		if endpoint.TLSCert != nil {
+			chainValid := 0
+			if endpoint.TLSCert.ChainValid {
+				chainValid = 1
+			}
+
+			b.WriteString("# HELP probe_ssl_earliest_cert_expiry Returns earliest SSL cert expiry date as unix timestamp\n")
+			b.WriteString("# TYPE probe_ssl_earliest_cert_expiry gauge\n")
+			b.WriteString(fmt.Sprintf("probe_ssl_earliest_cert_expiry{%s} %d\n", labels, endpoint.TLSCert.EarliestExpiry.Unix()))
+
+			b.WriteString("# HELP probe_ssl_chain_valid Displays whether the presented certificate chain verifies (1 = valid)\n")
+			b.WriteString("# TYPE probe_ssl_chain_valid gauge\n")
+			b.WriteString(fmt.Sprintf("probe_ssl_chain_valid{%s} %d\n", labels, chainValid))
+		}
+
 		// Last check timestamp
 		b.WriteString("# HELP probe_last_check_timestamp Last check timestamp\n")
 		b.WriteString("# TYPE probe_last_check_timestamp gauge\n")
@@ -82,13 +189,19 @@ func (mc *MetricsCollector) GetMetrics() string {
 		// Check interval
 		b.WriteString("# HELP probe_interval_seconds Check interval in seconds\n")
 		b.WriteString("# TYPE probe_interval_seconds gauge\n")
-		b.WriteString(fmt.Sprintf("probe_interval_seconds{%s} %d\n", labels, endpoint.Interval))
+		b.WriteString(fmt.Sprintf("probe_interval_seconds{%s} %g\n", labels, endpoint.Interval))
+
+		b.WriteString("# HELP probe_maintenance Whether the endpoint is in a maintenance window\n")
+		b.WriteString("# TYPE probe_maintenance gauge\n")
+		b.WriteString(fmt.Sprintf("probe_maintenance{%s} %d\n", labels, inMaintenance))
 	}
 
 	// Summary metrics
 	totalEndpoints := len(mc.endpoints)
 	upEndpoints := 0
 	downEndpoints := 0
+	degradedEndpoints := 0
+	maintenanceEndpoints := 0
 
 	for _, endpoint := range mc.endpoints {
 		switch endpoint.Status {
@@ -96,6 +209,10 @@ func (mc *MetricsCollector) GetMetrics() string {
 			upEndpoints++
 		case "down":
 			downEndpoints++
+		case "degraded":
+			degradedEndpoints++
+		case "maintenance":
+			maintenanceEndpoints++
 		}
 	}
 
@@ -111,9 +228,108 @@ func (mc *MetricsCollector) GetMetrics() string {
 	b.WriteString("# TYPE health_monitoring_down_endpoints gauge\n")
 	b.WriteString(fmt.Sprintf("health_monitoring_down_endpoints %d\n", downEndpoints))
 
+	b.WriteString("# HELP health_monitoring_degraded_endpoints Number of degraded endpoints\n")
+	b.WriteString("# TYPE health_monitoring_degraded_endpoints gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_degraded_endpoints %d\n", degradedEndpoints))
+
+	b.WriteString("# HELP health_monitoring_maintenance_endpoints Number of endpoints in a maintenance window\n")
+	b.WriteString("# TYPE health_monitoring_maintenance_endpoints gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_maintenance_endpoints %d\n", maintenanceEndpoints))
+
+	mc.writeReloadMetrics(&b)
+
+	if mc.poolStats != nil {
+		mc.writePoolMetrics(&b, mc.poolStats())
+	}
+
 	return b.String()
 }
 
+// writeReloadMetrics adds the configuration reload counters
+func (mc *MetricsCollector) writeReloadMetrics(b *strings.Builder) {
+	lastOK := 0
+	if mc.reloads.lastOK {
+		lastOK = 1
+	}
+
+	b.WriteString("# HELP health_monitoring_config_reloads_total Number of configuration reload attempts\n")
+	b.WriteString("# TYPE health_monitoring_config_reloads_total counter\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_config_reloads_total{result=\"success\"} %d\n", mc.reloads.successes))
+	b.WriteString(fmt.Sprintf("health_monitoring_config_reloads_total{result=\"failure\"} %d\n", mc.reloads.failures))
+
+	b.WriteString("# HELP health_monitoring_config_last_reload_successful Whether the last configuration reload succeeded\n")
+	b.WriteString("# TYPE health_monitoring_config_last_reload_successful gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_config_last_reload_successful %d\n", lastOK))
+
+	b.WriteString("# HELP health_monitoring_config_last_reload_success_timestamp_seconds Time of the last successful configuration load\n")
+	b.WriteString("# TYPE health_monitoring_config_last_reload_success_timestamp_seconds gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_config_last_reload_success_timestamp_seconds %d\n", mc.reloads.lastSuccess.Unix()))
+}
+
+// writePoolMetrics writes the probe pool queue depth and saturation
+func (mc *MetricsCollector) writePoolMetrics(b *strings.Builder, stats models.PoolStats) {
+	saturation := 0.0
+	if stats.Capacity > 0 {
+		saturation = float64(stats.Running) / float64(stats.Capacity)
+	}
+
+	b.WriteString("# HELP health_monitoring_probes_in_flight Number of probes currently running\n")
+	b.WriteString("# TYPE health_monitoring_probes_in_flight gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_probes_in_flight %d\n", stats.Running))
+
+	b.WriteString("# HELP health_monitoring_probe_queue_depth Number of due probes waiting for a free slot\n")
+	b.WriteString("# TYPE health_monitoring_probe_queue_depth gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_probe_queue_depth %d\n", stats.Queued))
+
+	b.WriteString("# HELP health_monitoring_probe_concurrency_limit Maximum number of concurrent probes\n")
+	b.WriteString("# TYPE health_monitoring_probe_concurrency_limit gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_probe_concurrency_limit %d\n", stats.Capacity))
+
+	b.WriteString("# HELP health_monitoring_probe_pool_saturation Ratio of running probes to the concurrency limit\n")
+	b.WriteString("# TYPE health_monitoring_probe_pool_saturation gauge\n")
+	b.WriteString(fmt.Sprintf("health_monitoring_probe_pool_saturation %.3f\n", saturation))
+
+	if stats.GroupLabel == "" {
+		return
+	}
+
+	groups := make(map[string]bool)
+	for group := range stats.GroupRunning {
+		groups[group] = true
+	}
+	for group := range stats.GroupQueued {
+		groups[group] = true
+	}
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	label := models.SanitizeLabelName(stats.GroupLabel)
+
+	b.WriteString("# HELP health_monitoring_group_probes_in_flight Number of running probes per group\n")
+	b.WriteString("# TYPE health_monitoring_group_probes_in_flight gauge\n")
+	for _, group := range names {
+		b.WriteString(fmt.Sprintf("health_monitoring_group_probes_in_flight{%s=\"%s\"} %d\n", label, mc.escapeLabelValue(group), stats.GroupRunning[group]))
+	}
+
+	b.WriteString("# HELP health_monitoring_group_probe_queue_depth Number of queued probes per group\n")
+	b.WriteString("# TYPE health_monitoring_group_probe_queue_depth gauge\n")
+	for _, group := range names {
+		b.WriteString(fmt.Sprintf("health_monitoring_group_probe_queue_depth{%s=\"%s\"} %d\n", label, mc.escapeLabelValue(group), stats.GroupQueued[group]))
+	}
+}
+
+// grpcCodeValue converts a gRPC status code name back to its numeric value
+func grpcCodeValue(name string) int {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if code.String() == name {
+			return int(code)
+		}
+	}
+	return int(codes.Unknown)
+}
+
 // buildLabels builds the label string for metrics
 func (mc *MetricsCollector) buildLabels(endpoint *models.Endpoint) string {
 	labels := []string{
@@ -132,7 +348,7 @@ func (mc *MetricsCollector) buildLabels(endpoint *models.Endpoint) string {
 			value := endpoint.Labels[key]
 			// Escape quotes in label values
 			escapedValue := mc.escapeLabelValue(value)
-			labels = append(labels, fmt.Sprintf("%s=\"%s\"", key, escapedValue))
+			labels = append(labels, fmt.Sprintf("%s=\"%s\"", models.SanitizeLabelName(key), escapedValue))
 		}
 	}
 
@@ -1,26 +1,182 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Endpoint represents a monitored endpoint
 type Endpoint struct {
-	ID           string            `json:"id"`
-	Name         string            `json:"name"`
-	URL          string            `json:"url"`
-	Method       string            `json:"method"`
-	Interval     int               `json:"interval"` // in seconds
-	Timeout      int               `json:"timeout"`  // in seconds
-	LastCheck    time.Time         `json:"lastCheck"`
-	Status       string            `json:"status"` // "up", "down", "checking"
-	StatusCode   int               `json:"statusCode"`
-	ResponseTime int64             `json:"responseTime"` // in milliseconds
-	Error        string            `json:"error,omitempty"`
-	Labels       map[string]string `json:"labels,omitempty"`     // Additional labels for metrics
-	ProbeType    string            `json:"probe_type,omitempty"` // e.g., "livez", "readyz", "healthz"
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	URL                  string            `json:"url"`
+	Method               string            `json:"method"`
+	Interval             float64           `json:"interval"` // in seconds, fractions allowed
+	Timeout              int               `json:"timeout"`  // in seconds
+	LastCheck            time.Time         `json:"lastCheck"`
+	Status               string            `json:"status"`               // "up", "down", "degraded", "maintenance", "checking"
+	LastResult           string            `json:"lastResult,omitempty"` // Raw outcome of the latest check, before thresholds
+	ConsecutiveFailures  int               `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int               `json:"consecutiveSuccesses"`
+	Attempts             []AttemptResult   `json:"attempts,omitempty"` // Per-attempt results of the latest check
+	StatusCode           int               `json:"statusCode"`
+	ResponseTime         int64             `json:"responseTime"`               // in milliseconds
+	ConnectTime          int64             `json:"connectTime,omitempty"`      // in milliseconds, TCP probes only
+	DNSRcode             string            `json:"dnsRcode,omitempty"`         // Response code of the last DNS query
+	DNSAnswers           []string          `json:"dnsAnswers,omitempty"`       // Answers of the queried type
+	TLSCert              *TLSCertInfo      `json:"tlsCert,omitempty"`          // Peer certificate of the last HTTPS check
+	GRPCCode             string            `json:"grpcCode,omitempty"`         // gRPC status code of the last health call
+	GRPCStatus           string            `json:"grpcStatus,omitempty"`       // Serving status reported by the health service
+	AssertionResults     []AssertionResult `json:"assertionResults,omitempty"` // Per-assertion outcome of the last HTTP check
+	Error                string            `json:"error,omitempty"`
+	IncidentID           string            `json:"incidentId,omitempty"`        // Open incident while the endpoint is down
+	AckedBy              string            `json:"ackedBy,omitempty"`           // Who acknowledged the open incident
+	MaintenanceID        string            `json:"maintenanceId,omitempty"`     // Active maintenance window
+	Labels               map[string]string `json:"labels,omitempty"`            // Additional labels for metrics
+	ProbeType            string            `json:"probe_type,omitempty"`        // e.g., "livez", "readyz", "healthz"
+	Source               string            `json:"source,omitempty"`            // SourceConfig or SourceAPI
+	TCP                  *TCPProbe         `json:"tcp,omitempty"`               // Options for tcp:// endpoints
+	DNS                  *DNSProbe         `json:"dns,omitempty"`               // Options for dns:// endpoints
+	GRPC                 *GRPCProbe        `json:"grpc,omitempty"`              // Options for grpc:// and grpcs:// endpoints
+	TLS                  *TLSPolicy        `json:"tls,omitempty"`               // TLS policy for https:// and grpcs:// endpoints
+	Assertions           *HTTPAssertions   `json:"assertions,omitempty"`        // Checks on the HTTP response
+	Headers              map[string]string `json:"-"`                           // Request headers for HTTP probes, from the config file only
+	Body                 string            `json:"body,omitempty"`              // Inline request body
+	BodyFile             string            `json:"-"`                           // File holding the request body, from the config file only
+	Auth                 *HTTPAuth         `json:"-"`                           // Request authentication, from the config file only
+	Retry                *RetryPolicy      `json:"retry,omitempty"`             // Retries within a single check
+	FailureThreshold     int               `json:"failure_threshold,omitempty"` // Consecutive failed checks before going down
+	SuccessThreshold     int               `json:"success_threshold,omitempty"` // Consecutive successful checks before recovering
+	References           []Reference       `json:"-"`                           // Fields expanded from ${...} references in the config file
+}
+
+// Probe kinds, derived from the endpoint URL scheme
+const (
+	ProbeKindHTTP = "http"
+	ProbeKindTCP  = "tcp"
+	ProbeKindDNS  = "dns"
+	ProbeKindGRPC = "grpc"
+)
+
+// Endpoint sources, recording where an endpoint was defined
+const (
+	SourceConfig = "config"
+	SourceAPI    = "api"
+)
+
+// TCPProbe holds the options for a TCP connect probe
+type TCPProbe struct {
+	Send   string `json:"send,omitempty"`   // Payload written after connecting
+	Expect string `json:"expect,omitempty"` // Regular expression the response must match
+}
+
+// DNSProbe holds the query and assertions for a DNS probe
+type DNSProbe struct {
+	QueryName     string   `json:"query_name"`               // Name to resolve
+	QueryType     string   `json:"query_type,omitempty"`     // A, AAAA, CNAME, MX, TXT or SRV (default A)
+	Transport     string   `json:"transport,omitempty"`      // "udp" (default) or "tcp"
+	ExpectedRcode string   `json:"expected_rcode,omitempty"` // e.g. NOERROR (default), NXDOMAIN
+	ExpectedIPs   []string `json:"expected_ips,omitempty"`   // Addresses that must all be in the answer
+	MinRecords    int      `json:"min_records,omitempty"`    // Minimum number of answers of the queried type
+}
+
+// GRPCProbe holds the options for a grpc.health.v1.Health probe
+type GRPCProbe struct {
+	Service  string `json:"service,omitempty"`   // Service name to check; empty checks the whole server
+	UseWatch bool   `json:"use_watch,omitempty"` // Use the streaming Watch call instead of Check
+}
+
+// TLSPolicy controls certificate verification for HTTPS probes
+type TLSPolicy struct {
+	Verify              bool   `json:"verify,omitempty"`                // Fail the handshake on an invalid chain
+	CAFile              string `json:"ca_file,omitempty"`               // PEM bundle used instead of the system roots
+	ServerName          string `json:"server_name,omitempty"`           // SNI and name to verify against
+	ExpiryThresholdDays int    `json:"expiry_threshold_days,omitempty"` // Flag certificates expiring sooner than this
+	ExpiryStatus        string `json:"expiry_status,omitempty"`         // "degraded" (default) or "down"
+}
+
+// TLSCertInfo describes the certificate presented by an HTTPS endpoint
+type TLSCertInfo struct {
+	Subject        string    `json:"subject"`
+	Issuer         string    `json:"issuer"`
+	DNSNames       []string  `json:"dnsNames,omitempty"`
+	NotAfter       time.Time `json:"notAfter"`
+	EarliestExpiry time.Time `json:"earliestExpiry"` // Earliest notAfter across the presented chain
+	ChainValid     bool      `json:"chainValid"`
+	ChainError     string    `json:"chainError,omitempty"`
+}
+
+// HTTPAssertions describes the checks applied to an HTTP response.
+// When StatusCodes is empty, any status from 200 to 399 passes.
+type HTTPAssertions struct {
+	StatusCodes  []string            `json:"status_codes,omitempty"`   // e.g. "200", "2xx", "200-204"
+	BodyContains string              `json:"body_contains,omitempty"`  // Substring the body must contain
+	BodyRegex    string              `json:"body_regex,omitempty"`     // Regular expression the body must match
+	JSONPath     []JSONPathAssertion `json:"json_path,omitempty"`      // Values extracted from a JSON body
+	Headers      map[string]string   `json:"headers,omitempty"`        // Required headers; a value is matched as a regex
+	MaxBodyBytes int64               `json:"max_body_bytes,omitempty"` // Fail when the body is larger than this
+}
+
+// JSONPathAssertion expects the value at a JSONPath expression such as $.status
+type JSONPathAssertion struct {
+	Path  string `json:"path"`
+	Value string `json:"value,omitempty"` // Expected value; empty only requires the path to exist
+}
+
+// AssertionResult is the outcome of a single assertion
+type AssertionResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// RetryPolicy retries a failed probe within the same check
+type RetryPolicy struct {
+	Attempts   int     `json:"attempts"`              // Additional attempts after the first failure
+	Backoff    float64 `json:"backoff,omitempty"`     // Initial delay in seconds, doubled after every retry
+	MaxBackoff float64 `json:"max_backoff,omitempty"` // Upper bound for the delay in seconds
+}
+
+// AttemptResult is the raw outcome of a single probe attempt
+type AttemptResult struct {
+	Time         time.Time `json:"time"`
+	Status       string    `json:"status"`
+	StatusCode   int       `json:"statusCode"`
+	ResponseTime int64     `json:"responseTime"` // in milliseconds
+	Error        string    `json:"error,omitempty"`
+}
+
+// SecretRef points at a secret held outside the configuration
+type SecretRef struct {
+	File string `json:"file,omitempty"` // Read the secret from this file
+	Env  string `json:"env,omitempty"`  // Read the secret from this environment variable
+}
+
+// HTTPAuth configures exactly one authentication scheme for HTTP probes
+type HTTPAuth struct {
+	Basic  *BasicAuth               `json:"basic,omitempty"`
+	Bearer *SecretRef               `json:"bearer,omitempty"`
+	OAuth2 *OAuth2ClientCredentials `json:"oauth2,omitempty"`
+}
+
+// BasicAuth holds HTTP basic authentication credentials
+type BasicAuth struct {
+	Username string    `json:"username"`
+	Password SecretRef `json:"password"`
+}
+
+// OAuth2ClientCredentials fetches bearer tokens with the client credentials grant
+type OAuth2ClientCredentials struct {
+	TokenURL       string            `json:"token_url"`
+	ClientID       string            `json:"client_id"`
+	ClientSecret   SecretRef         `json:"client_secret"`
+	Scopes         []string          `json:"scopes,omitempty"`
+	EndpointParams map[string]string `json:"endpoint_params,omitempty"` // Extra form values, e.g. audience
+	AuthStyle      string            `json:"auth_style,omitempty"`      // "header" (default) or "params"
 }
 
 // NewEndpoint creates a new endpoint with default values
-func NewEndpoint(name, url, method string, interval, timeout int) *Endpoint {
+func NewEndpoint(name, url, method string, interval float64, timeout int) *Endpoint {
 	return &Endpoint{
 		Name:     name,
 		URL:      url,
@@ -31,6 +187,25 @@ func NewEndpoint(name, url, method string, interval, timeout int) *Endpoint {
 	}
 }
 
+// Kind returns the probe kind for the endpoint based on its URL scheme
+func (e *Endpoint) Kind() string {
+	switch {
+	case strings.HasPrefix(e.URL, "tcp://"):
+		return ProbeKindTCP
+	case strings.HasPrefix(e.URL, "dns://"):
+		return ProbeKindDNS
+	case strings.HasPrefix(e.URL, "grpc://"), strings.HasPrefix(e.URL, "grpcs://"):
+		return ProbeKindGRPC
+	default:
+		return ProbeKindHTTP
+	}
+}
+
+// IntervalDuration returns the check interval as a duration
+func (e *Endpoint) IntervalDuration() time.Duration {
+	return time.Duration(e.Interval * float64(time.Second))
+}
+
 // IsHealthy returns true if the endpoint is up
 func (e *Endpoint) IsHealthy() bool {
 	return e.Status == "up"
@@ -43,6 +218,10 @@ func (e *Endpoint) GetStatusColor() string {
 		return "status-up"
 	case "down":
 		return "status-down"
+	case "degraded":
+		return "status-degraded"
+	case "maintenance":
+		return "status-maintenance"
 	case "checking":
 		return "status-checking"
 	default:
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CheckResult is a single entry in an endpoint's check history
+type CheckResult struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Status       string    `json:"status"` // Raw result of the check: "up", "down" or "degraded"
+	StatusCode   int       `json:"statusCode"`
+	ResponseTime int64     `json:"responseTime"` // in milliseconds
+	Error        string    `json:"error,omitempty"`
+	Maintenance  bool      `json:"maintenance,omitempty"` // Taken during a maintenance window
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+// endpointIDPattern matches the characters allowed in an endpoint ID, which
+// appears in API paths and dashboard links
+var endpointIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// EndpointID derives the ID of an endpoint that sets none from its name and
+// URL, so that the same endpoint keeps its ID across restarts
+func EndpointID(name, url string) string {
+	sum := sha256.Sum256([]byte(name + "\n" + url))
+	return "endpoint_" + hex.EncodeToString(sum[:8])
+}
+
+// ValidateEndpointID checks that an ID can be used in API paths
+func ValidateEndpointID(id string) error {
+	if !endpointIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid id %q: use up to 128 letters, digits, '_', '.' or '-', starting with a letter or digit", id)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestEndpointID(t *testing.T) {
+	id := EndpointID("Orders API", "https://orders.internal/healthz")
+	if id != EndpointID("Orders API", "https://orders.internal/healthz") {
+		t.Fatalf("EndpointID is not stable")
+	}
+	if err := ValidateEndpointID(id); err != nil {
+		t.Fatalf("derived ID %q is invalid: %v", id, err)
+	}
+
+	for _, other := range []string{
+		EndpointID("Orders API", "https://orders.internal/readyz"),
+		EndpointID("Orders", "https://orders.internal/healthz"),
+		// The separator keeps name and URL apart
+		EndpointID("Orders APIhttps://orders.internal/healthz", ""),
+	} {
+		if other == id {
+			t.Errorf("different endpoints share ID %q", id)
+		}
+	}
+}
+
+func TestValidateEndpointID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"orders-api", true},
+		{"orders_api.v2", true},
+		{"endpoint_1714564830000000000", true},
+		{"", false},
+		{"-orders", false},
+		{"orders/api", false},
+		{"orders api", false},
+		{string(make([]byte, 129)), false},
+	}
+	for _, test := range tests {
+		err := ValidateEndpointID(test.id)
+		if (err == nil) != test.valid {
+			t.Errorf("ValidateEndpointID(%q) = %v, want valid %v", test.id, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// Incident states, derived from the resolution and acknowledgement times
+const (
+	IncidentOpen         = "open"
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+// Incident timeline event types
+const (
+	EventOpened       = "opened"
+	EventError        = "error"
+	EventAcknowledged = "acknowledged"
+	EventNote         = "note"
+	EventResolved     = "resolved"
+)
+
+// Incident records a period during which an endpoint was down
+type Incident struct {
+	ID           string            `json:"id"`
+	EndpointID   string            `json:"endpointId"`
+	EndpointName string            `json:"endpointName"`
+	URL          string            `json:"url,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"` // Endpoint labels when the incident opened
+	StartedAt    time.Time         `json:"startedAt"`
+	ResolvedAt   *time.Time        `json:"resolvedAt,omitempty"` // nil while the incident is open
+	FirstError   string            `json:"firstError,omitempty"`
+	LastError    string            `json:"lastError,omitempty"`
+	CheckCount   int               `json:"checkCount"` // Failed checks during the incident
+	AckedBy      string            `json:"ackedBy,omitempty"`
+	AckedAt      *time.Time        `json:"ackedAt,omitempty"`
+	Notes        []IncidentNote    `json:"notes,omitempty"`
+	Timeline     []IncidentEvent   `json:"timeline,omitempty"`
+}
+
+// IncidentNote is a free-form comment added to an incident
+type IncidentNote struct {
+	Time   time.Time `json:"time"`
+	Author string    `json:"author,omitempty"`
+	Text   string    `json:"text"`
+}
+
+// IncidentEvent is an entry of an incident's timeline
+type IncidentEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"` // One of the Event* constants
+	Message string    `json:"message,omitempty"`
+	By      string    `json:"by,omitempty"`
+}
+
+// IsOpen reports whether the incident has not been resolved yet
+func (i *Incident) IsOpen() bool {
+	return i.ResolvedAt == nil
+}
+
+// State returns IncidentOpen, IncidentAcknowledged or IncidentResolved
+func (i *Incident) State() string {
+	switch {
+	case !i.IsOpen():
+		return IncidentResolved
+	case i.AckedAt != nil:
+		return IncidentAcknowledged
+	default:
+		return IncidentOpen
+	}
+}
+
+// AddEvent appends an entry to the incident's timeline
+func (i *Incident) AddEvent(at time.Time, eventType, message, by string) {
+	i.Timeline = append(i.Timeline, IncidentEvent{Time: at, Type: eventType, Message: message, By: by})
+}
+
+// Copy returns a copy of the incident that shares no slices with it
+func (i *Incident) Copy() *Incident {
+	c := *i
+	c.Notes = append([]IncidentNote(nil), i.Notes...)
+	c.Timeline = append([]IncidentEvent(nil), i.Timeline...)
+	return &c
+}
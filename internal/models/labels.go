@@ -0,0 +1,18 @@
+package models
+
+// SanitizeLabelName turns a label key such as app.kubernetes.io/team into a
+// valid Prometheus label name by replacing invalid characters, including a
+// leading digit, with underscores
+func SanitizeLabelName(name string) string {
+	sanitized := []byte(name)
+	for i, char := range sanitized {
+		valid := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (i > 0 && char >= '0' && char <= '9')
+		if !valid {
+			sanitized[i] = '_'
+		}
+	}
+	if len(sanitized) == 0 {
+		return "_"
+	}
+	return string(sanitized)
+}
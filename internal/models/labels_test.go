@@ -0,0 +1,18 @@
+package models
+
+import "testing"
+
+func TestSanitizeLabelName(t *testing.T) {
+	tests := map[string]string{
+		"team":                   "team",
+		"app.kubernetes.io/team": "app_kubernetes_io_team",
+		"tier2":                  "tier2",
+		"2tier":                  "_tier",
+		"":                       "_",
+	}
+	for name, want := range tests {
+		if got := SanitizeLabelName(name); got != want {
+			t.Errorf("SanitizeLabelName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaintenanceWindow is a one-off or recurring period during which matching
+// endpoints are shown as in maintenance, are not alerted on and do not count
+// against their uptime
+type MaintenanceWindow struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name,omitempty"`
+	EndpointIDs []string          `json:"endpoint_ids,omitempty"` // Endpoints covered by the window
+	Matchers    map[string]string `json:"matchers,omitempty"`     // Endpoint labels that must all match
+	StartsAt    *time.Time        `json:"starts_at,omitempty"`    // Start of a one-off window, or DTSTART for an RRULE
+	EndsAt      *time.Time        `json:"ends_at,omitempty"`      // End of a one-off window
+	Cron        string            `json:"cron,omitempty"`         // Recurrence as a cron expression
+	RRule       string            `json:"rrule,omitempty"`        // Recurrence as an RFC 5545 RRULE
+	Duration    string            `json:"duration,omitempty"`     // Length of each occurrence, e.g. "2h"
+	Timezone    string            `json:"timezone,omitempty"`     // IANA time zone for recurrences (default UTC)
+	CreatedBy   string            `json:"created_by,omitempty"`
+	Comment     string            `json:"comment,omitempty"`
+	Source      string            `json:"source,omitempty"` // SourceConfig or SourceAPI
+}
+
+// IsRecurring reports whether the window repeats
+func (w *MaintenanceWindow) IsRecurring() bool {
+	return w.Cron != "" || w.RRule != ""
+}
+
+// Validate checks that the window selects endpoints and has exactly one schedule.
+// Recurrence expressions are parsed when the window is scheduled.
+func (w *MaintenanceWindow) Validate() error {
+	if len(w.EndpointIDs) == 0 && len(w.Matchers) == 0 {
+		return fmt.Errorf("maintenance window requires endpoint_ids or matchers")
+	}
+	if w.Cron != "" && w.RRule != "" {
+		return fmt.Errorf("maintenance window must not set both cron and rrule")
+	}
+
+	if w.Duration != "" {
+		duration, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", w.Duration, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("duration must be positive")
+		}
+	}
+
+	if w.IsRecurring() {
+		if w.Duration == "" {
+			return fmt.Errorf("recurring maintenance window requires a duration")
+		}
+		if w.EndsAt != nil {
+			return fmt.Errorf("recurring maintenance window must not set ends_at")
+		}
+		return nil
+	}
+
+	if w.StartsAt == nil {
+		return fmt.Errorf("maintenance window requires starts_at, cron or rrule")
+	}
+	if w.EndsAt == nil && w.Duration == "" {
+		return fmt.Errorf("one-off maintenance window requires ends_at or duration")
+	}
+	if w.EndsAt != nil && w.Duration != "" {
+		return fmt.Errorf("maintenance window must not set both ends_at and duration")
+	}
+	if w.EndsAt != nil && !w.EndsAt.After(*w.StartsAt) {
+		return fmt.Errorf("maintenance window must end after it starts")
+	}
+	return nil
+}
+
+// Matches reports whether the window covers an endpoint
+func (w *MaintenanceWindow) Matches(endpointID string, labels map[string]string) bool {
+	if len(w.EndpointIDs) > 0 {
+		found := false
+		for _, id := range w.EndpointIDs {
+			if id == endpointID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for name, value := range w.Matchers {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,11 @@
+package models
+
+// PoolStats is a snapshot of the probe worker pool utilisation
+type PoolStats struct {
+	Running      int            `json:"running"`
+	Queued       int            `json:"queued"`
+	Capacity     int            `json:"capacity"`
+	GroupLabel   string         `json:"groupLabel,omitempty"`
+	GroupRunning map[string]int `json:"groupRunning,omitempty"`
+	GroupQueued  map[string]int `json:"groupQueued,omitempty"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Reference records a configuration string that contained ${...} references.
+// Its expanded value may hold a secret, so it is shown in its raw form
+// wherever the configuration or an endpoint leaves the process.
+type Reference struct {
+	Path []string // JSON path of the value, e.g. ["labels", "team"]
+	Raw  string   // Value as written, before expansion
+}
+
+// ApplyReferences replaces the expanded values at the references' paths in a
+// decoded JSON value with their raw form. Paths that are not present are
+// skipped.
+func ApplyReferences(value interface{}, references []Reference) {
+	for _, reference := range references {
+		setPath(value, reference.Path, reference.Raw)
+	}
+}
+
+// setPath replaces the string at path in a decoded JSON value
+func setPath(value interface{}, path []string, raw string) {
+	if len(path) == 0 {
+		return
+	}
+	last := len(path) == 1
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if !last {
+			setPath(child, path[1:], raw)
+		} else if _, ok := child.(string); ok {
+			v[path[0]] = raw
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(v) {
+			return
+		}
+		if !last {
+			setPath(v[i], path[1:], raw)
+		} else if _, ok := v[i].(string); ok {
+			v[i] = raw
+		}
+	}
+}
+
+// Redacted returns the endpoint as it may be shown to clients and stored:
+// values expanded from ${...} references in the config file are replaced with
+// their raw form. Endpoints without references are returned as they are.
+func (e *Endpoint) Redacted() *Endpoint {
+	if len(e.References) == 0 {
+		return e
+	}
+
+	redacted, err := e.redact()
+	if err != nil {
+		// Fall back to the fields that never come from the config file
+		return &Endpoint{ID: e.ID, Status: e.Status, Source: e.Source, Error: err.Error()}
+	}
+	return redacted
+}
+
+func (e *Endpoint) redact() (*Endpoint, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	ApplyReferences(decoded, e.References)
+	if data, err = json.Marshal(decoded); err != nil {
+		return nil, err
+	}
+
+	redacted := &Endpoint{}
+	if err := json.Unmarshal(data, redacted); err != nil {
+		return nil, err
+	}
+	return redacted, nil
+}
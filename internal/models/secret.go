@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resolve returns the secret value from its file or environment variable.
+// Trailing newlines are trimmed from file contents.
+func (s SecretRef) Resolve() (string, error) {
+	switch {
+	case s.File != "" && s.Env != "":
+		return "", fmt.Errorf("secret must set only one of file or env")
+	case s.File != "":
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("secret environment variable %s is not set", s.Env)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("secret must set file or env")
+	}
+}
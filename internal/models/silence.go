@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Silence suppresses notifications for matching endpoints during a time window
+type Silence struct {
+	ID         string            `json:"id"`
+	EndpointID string            `json:"endpointId,omitempty"` // Silence a single endpoint
+	Matchers   map[string]string `json:"matchers,omitempty"`   // Endpoint labels that must all match
+	StartsAt   time.Time         `json:"startsAt"`
+	EndsAt     time.Time         `json:"endsAt"`
+	CreatedBy  string            `json:"createdBy,omitempty"`
+	Comment    string            `json:"comment,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+// Validate checks that the silence selects endpoints and has a valid window
+func (s *Silence) Validate() error {
+	if s.EndpointID == "" && len(s.Matchers) == 0 {
+		return fmt.Errorf("silence requires an endpointId or matchers")
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("silence must end after it starts")
+	}
+	return nil
+}
+
+// IsActive reports whether the silence is in effect at the given time
+func (s *Silence) IsActive(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Matches reports whether the silence selects the endpoint
+func (s *Silence) Matches(endpointID string, labels map[string]string) bool {
+	if s.EndpointID != "" && s.EndpointID != endpointID {
+		return false
+	}
+	for name, value := range s.Matchers {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
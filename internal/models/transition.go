@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Transition is a change of an endpoint's visible status between down and
+// healthy (up or degraded). It is a snapshot taken when the change happened.
+// An acknowledged transition carries no status change: it reports that the
+// ongoing outage was acknowledged.
+type Transition struct {
+	EndpointID   string            `json:"endpointId"`
+	EndpointName string            `json:"endpointName"`
+	URL          string            `json:"url"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	From         string            `json:"from"` // Previous status
+	To           string            `json:"to"`   // New status
+	StatusCode   int               `json:"statusCode"`
+	Error        string            `json:"error,omitempty"`
+	Time         time.Time         `json:"time"`
+	DownSince    time.Time         `json:"downSince"` // Start of the outage
+	IncidentID   string            `json:"incidentId,omitempty"`
+	Reminder     bool              `json:"reminder,omitempty"`     // Repeated notification for an ongoing outage
+	Acknowledged bool              `json:"acknowledged,omitempty"` // The outage's incident was acknowledged
+	AckedBy      string            `json:"ackedBy,omitempty"`
+}
+
+// IsRecovery reports whether the endpoint came back up
+func (t Transition) IsRecovery() bool {
+	return t.To != "down"
+}
+
+// Duration returns how long the endpoint has been, or was, down
+func (t Transition) Duration() time.Duration {
+	return t.Time.Sub(t.DownSince)
+}
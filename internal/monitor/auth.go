@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// tokenExpiryMargin refreshes cached OAuth2 tokens shortly before they expire
+const tokenExpiryMargin = 30 * time.Second
+
+// defaultTokenLifetime is used when a token response carries no expires_in
+const defaultTokenLifetime = 5 * time.Minute
+
+// oauth2Token is a cached access token
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+	expiry      time.Time
+}
+
+// tokenCache holds the token for one client. Its mutex is held while a token
+// is fetched, so that concurrent checks wait for the same fetch without
+// blocking the checks of other clients.
+type tokenCache struct {
+	mutex sync.Mutex
+	token *oauth2Token
+}
+
+// buildRequest creates the HTTP request for an endpoint, including headers,
+// body and authentication
+func (m *Monitor) buildRequest(ctx context.Context, endpoint *models.Endpoint) (*http.Request, error) {
+	var body io.Reader
+	switch {
+	case endpoint.BodyFile != "":
+		data, err := os.ReadFile(endpoint.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body file: %v", err)
+		}
+		body = strings.NewReader(string(data))
+	case endpoint.Body != "":
+		body = strings.NewReader(endpoint.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, endpoint.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range endpoint.Headers {
+		req.Header.Set(name, value)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	if err := m.applyAuth(ctx, req, endpoint.Auth); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// applyAuth adds the configured credentials to the request
+func (m *Monitor) applyAuth(ctx context.Context, req *http.Request, auth *models.HTTPAuth) error {
+	if auth == nil {
+		return nil
+	}
+
+	switch {
+	case auth.Basic != nil:
+		password, err := auth.Basic.Password.Resolve()
+		if err != nil {
+			return fmt.Errorf("basic auth: %v", err)
+		}
+		req.SetBasicAuth(auth.Basic.Username, password)
+
+	case auth.Bearer != nil:
+		token, err := auth.Bearer.Resolve()
+		if err != nil {
+			return fmt.Errorf("bearer auth: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+	case auth.OAuth2 != nil:
+		token, err := m.oauth2Token(ctx, auth.OAuth2)
+		if err != nil {
+			return fmt.Errorf("oauth2: %v", err)
+		}
+		tokenType := token.tokenType
+		if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+			tokenType = "Bearer"
+		}
+		req.Header.Set("Authorization", tokenType+" "+token.accessToken)
+	}
+
+	return nil
+}
+
+// oauth2Token returns a cached client credentials token, fetching a new one
+// when none is cached or the cached one is about to expire
+func (m *Monitor) oauth2Token(ctx context.Context, cfg *models.OAuth2ClientCredentials) (*oauth2Token, error) {
+	key := cfg.TokenURL + "|" + cfg.ClientID + "|" + strings.Join(cfg.Scopes, " ")
+
+	m.tokenMutex.Lock()
+	cache, ok := m.tokens[key]
+	if !ok {
+		cache = &tokenCache{}
+		m.tokens[key] = cache
+	}
+	m.tokenMutex.Unlock()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.token != nil && time.Now().Add(tokenExpiryMargin).Before(cache.token.expiry) {
+		return cache.token, nil
+	}
+
+	token, err := fetchOAuth2Token(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cache.token = token
+	return token, nil
+}
+
+// fetchOAuth2Token performs the client credentials grant against the token URL
+func fetchOAuth2Token(ctx context.Context, cfg *models.OAuth2ClientCredentials) (*oauth2Token, error) {
+	secret, err := cfg.ClientSecret.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	for key, value := range cfg.EndpointParams {
+		form.Set(key, value)
+	}
+	if cfg.AuthStyle == "params" {
+		form.Set("client_id", cfg.ClientID)
+		form.Set("client_secret", secret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.AuthStyle != "params" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(secret))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid token response: %v", err)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+
+	lifetime := defaultTokenLifetime
+	if payload.ExpiresIn > 0 {
+		lifetime = time.Duration(payload.ExpiresIn) * time.Second
+	}
+
+	return &oauth2Token{
+		accessToken: payload.AccessToken,
+		tokenType:   payload.TokenType,
+		expiry:      time.Now().Add(lifetime),
+	}, nil
+}
@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-caretaker/internal/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsQueryTypes maps the configured query type to its wire type
+var dnsQueryTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"SRV":   dnsmessage.TypeSRV,
+}
+
+// dnsRcodeNames maps response codes to their conventional names
+var dnsRcodeNames = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+// checkDNS queries the resolver in a dns://resolver[:port] URL and asserts on the answer
+func (m *Monitor) checkDNS(endpoint *models.Endpoint) {
+	start := time.Now()
+	timeout := time.Duration(endpoint.Timeout) * time.Second
+
+	endpoint.StatusCode = 0
+	endpoint.DNSRcode = ""
+	endpoint.DNSAnswers = nil
+
+	rcode, answers, err := queryDNS(endpoint.URL, endpoint.DNS, timeout)
+
+	endpoint.LastCheck = time.Now()
+	endpoint.ResponseTime = time.Since(start).Milliseconds()
+
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		return
+	}
+
+	endpoint.DNSRcode = rcode
+	endpoint.DNSAnswers = answers
+
+	if err := assertDNS(endpoint.DNS, rcode, answers); err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		return
+	}
+
+	endpoint.LastResult = "up"
+	endpoint.Error = ""
+}
+
+// queryDNS sends a single query to the resolver and returns the response code
+// together with the answers matching the queried type
+func queryDNS(rawURL string, probe *models.DNSProbe, timeout time.Duration) (string, []string, error) {
+	if probe == nil || probe.QueryName == "" {
+		return "", nil, fmt.Errorf("no DNS query configured")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	server := u.Host
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+
+	queryType := strings.ToUpper(probe.QueryType)
+	if queryType == "" {
+		queryType = "A"
+	}
+	qtype, ok := dnsQueryTypes[queryType]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported query type %q", probe.QueryType)
+	}
+
+	name := probe.QueryName
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid query name: %v", err)
+	}
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build query: %v", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	transport := probe.Transport
+	if transport == "" {
+		transport = "udp"
+	}
+
+	resp, err := exchangeDNS(transport, server, packed, deadline)
+	if err == nil && resp.Header.Truncated && transport == "udp" {
+		resp, err = exchangeDNS("tcp", server, packed, deadline)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.Header.ID != query.Header.ID || !resp.Header.Response {
+		return "", nil, fmt.Errorf("unexpected DNS response from %s", server)
+	}
+
+	rcode, ok := dnsRcodeNames[resp.Header.RCode]
+	if !ok {
+		rcode = strconv.Itoa(int(resp.Header.RCode))
+	}
+
+	var answers []string
+	for _, rr := range resp.Answers {
+		if rr.Header.Type != qtype {
+			continue
+		}
+		if answer := formatDNSResource(rr.Body); answer != "" {
+			answers = append(answers, answer)
+		}
+	}
+
+	return rcode, answers, nil
+}
+
+// exchangeDNS sends a packed query over the given transport and unpacks the response
+func exchangeDNS(transport, server string, query []byte, deadline time.Time) (*dnsmessage.Message, error) {
+	conn, err := net.DialTimeout(transport, server, time.Until(deadline))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	if transport == "tcp" {
+		// DNS over TCP prefixes every message with its two-byte length
+		framed := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(framed, uint16(len(query)))
+		copy(framed[2:], query)
+		if _, err := conn.Write(framed); err != nil {
+			return nil, err
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+
+		buf = make([]byte, 65535)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, fmt.Errorf("failed to parse DNS response: %v", err)
+	}
+	return &resp, nil
+}
+
+// formatDNSResource renders a resource record body as a short string
+func formatDNSResource(body dnsmessage.ResourceBody) string {
+	switch rr := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(rr.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(rr.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return rr.CNAME.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", rr.Pref, rr.MX.String())
+	case *dnsmessage.TXTResource:
+		return strings.Join(rr.TXT, "")
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", rr.Priority, rr.Weight, rr.Port, rr.Target.String())
+	default:
+		return ""
+	}
+}
+
+// assertDNS checks the response code and answers against the probe expectations
+func assertDNS(probe *models.DNSProbe, rcode string, answers []string) error {
+	expectedRcode := strings.ToUpper(probe.ExpectedRcode)
+	if expectedRcode == "" {
+		expectedRcode = "NOERROR"
+	}
+	if rcode != expectedRcode {
+		return fmt.Errorf("DNS rcode %s, expected %s", rcode, expectedRcode)
+	}
+
+	if len(answers) < probe.MinRecords {
+		return fmt.Errorf("DNS returned %d records, expected at least %d", len(answers), probe.MinRecords)
+	}
+
+	for _, expected := range probe.ExpectedIPs {
+		expectedIP := net.ParseIP(expected)
+		found := false
+		for _, answer := range answers {
+			if ip := net.ParseIP(answer); ip != nil && ip.Equal(expectedIP) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("DNS answer does not contain %s", expected)
+		}
+	}
+
+	return nil
+}
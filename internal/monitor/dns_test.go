@@ -0,0 +1,181 @@
+package monitor
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"health-caretaker/internal/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsRecords are the answers of the test resolver by query name
+var dnsRecords = map[string][]dnsmessage.Resource{
+	"ok.test.": {
+		dnsResource("ok.test.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}),
+		dnsResource("ok.test.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}}),
+		dnsResource("ok.test.", dnsmessage.TypeAAAA, &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}}),
+	},
+	"alias.test.": {
+		dnsResource("alias.test.", dnsmessage.TypeCNAME, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("ok.test.")}),
+	},
+}
+
+func dnsResource(name string, typ dnsmessage.Type, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   body,
+	}
+}
+
+// startDNSServer answers queries from dnsRecords on a loopback UDP port,
+// returning NXDOMAIN for unknown names and never answering for slow.test
+func startDNSServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			if question.Name.String() == "slow.test." {
+				continue
+			}
+
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.Header.ID, Response: true, RecursionDesired: query.Header.RecursionDesired},
+				Questions: query.Questions,
+			}
+			records, known := dnsRecords[question.Name.String()]
+			if !known {
+				resp.Header.RCode = dnsmessage.RCodeNameError
+			}
+			for _, record := range records {
+				// CNAMEs are answered for any type, as a resolver would
+				if record.Header.Type == question.Type || record.Header.Type == dnsmessage.TypeCNAME {
+					resp.Answers = append(resp.Answers, record)
+				}
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				t.Errorf("packing response: %v", err)
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return "dns://" + conn.LocalAddr().String()
+}
+
+func TestCheckDNS(t *testing.T) {
+	resolver := startDNSServer(t)
+
+	tests := []struct {
+		name    string
+		probe   models.DNSProbe
+		result  string
+		rcode   string
+		answers []string
+		err     string
+	}{
+		{
+			name:    "A",
+			probe:   models.DNSProbe{QueryName: "ok.test"},
+			result:  "up",
+			rcode:   "NOERROR",
+			answers: []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name:    "AAAA",
+			probe:   models.DNSProbe{QueryName: "ok.test", QueryType: "aaaa"},
+			result:  "up",
+			rcode:   "NOERROR",
+			answers: []string{"2001:db8::1"},
+		},
+		{
+			name:    "CNAME",
+			probe:   models.DNSProbe{QueryName: "alias.test", QueryType: "CNAME"},
+			result:  "up",
+			rcode:   "NOERROR",
+			answers: []string{"ok.test."},
+		},
+		{
+			name:    "CNAME without records of the queried type",
+			probe:   models.DNSProbe{QueryName: "alias.test", MinRecords: 1},
+			result:  "down",
+			rcode:   "NOERROR",
+			answers: nil,
+			err:     "DNS returned 0 records, expected at least 1",
+		},
+		{
+			name:    "expected IPs present",
+			probe:   models.DNSProbe{QueryName: "ok.test", ExpectedIPs: []string{"192.0.2.2"}, MinRecords: 2},
+			result:  "up",
+			rcode:   "NOERROR",
+			answers: []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name:    "expected IP missing",
+			probe:   models.DNSProbe{QueryName: "ok.test", ExpectedIPs: []string{"192.0.2.1", "192.0.2.9"}},
+			result:  "down",
+			rcode:   "NOERROR",
+			answers: []string{"192.0.2.1", "192.0.2.2"},
+			err:     "DNS answer does not contain 192.0.2.9",
+		},
+		{
+			name:   "NXDOMAIN",
+			probe:  models.DNSProbe{QueryName: "missing.test"},
+			result: "down",
+			rcode:  "NXDOMAIN",
+			err:    "DNS rcode NXDOMAIN, expected NOERROR",
+		},
+		{
+			name:   "expected NXDOMAIN",
+			probe:  models.DNSProbe{QueryName: "missing.test", ExpectedRcode: "nxdomain"},
+			result: "up",
+			rcode:  "NXDOMAIN",
+		},
+		{
+			name:   "timeout",
+			probe:  models.DNSProbe{QueryName: "slow.test"},
+			result: "down",
+			err:    "timeout",
+		},
+	}
+
+	m := NewMonitor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := tt.probe
+			endpoint := &models.Endpoint{ID: "dns", URL: resolver, Timeout: 1, ProbeType: "dns", DNS: &probe}
+			m.checkDNS(endpoint)
+
+			if endpoint.LastResult != tt.result {
+				t.Errorf("result = %q (%s), want %q", endpoint.LastResult, endpoint.Error, tt.result)
+			}
+			if endpoint.DNSRcode != tt.rcode {
+				t.Errorf("rcode = %q, want %q", endpoint.DNSRcode, tt.rcode)
+			}
+			if strings.Join(endpoint.DNSAnswers, ",") != strings.Join(tt.answers, ",") {
+				t.Errorf("answers = %v, want %v", endpoint.DNSAnswers, tt.answers)
+			}
+			if tt.err == "" && endpoint.Error != "" || !strings.Contains(endpoint.Error, tt.err) {
+				t.Errorf("error = %q, want %q", endpoint.Error, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"health-caretaker/internal/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// checkGRPC calls the standard gRPC health service on a grpc://host:port
+// (plaintext) or grpcs://host:port (TLS) endpoint
+func (m *Monitor) checkGRPC(endpoint *models.Endpoint) {
+	start := time.Now()
+	timeout := time.Duration(endpoint.Timeout) * time.Second
+
+	endpoint.StatusCode = 0
+	endpoint.GRPCCode = ""
+	endpoint.GRPCStatus = ""
+
+	servingStatus, err := callGRPCHealth(endpoint, timeout)
+
+	endpoint.LastCheck = time.Now()
+	endpoint.ResponseTime = time.Since(start).Milliseconds()
+	endpoint.GRPCCode = status.Code(err).String()
+
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		return
+	}
+
+	endpoint.GRPCStatus = servingStatus.String()
+	if servingStatus != healthpb.HealthCheckResponse_SERVING {
+		endpoint.LastResult = "down"
+		endpoint.Error = fmt.Sprintf("gRPC health status %s", servingStatus)
+		return
+	}
+
+	endpoint.LastResult = "up"
+	endpoint.Error = ""
+}
+
+// callGRPCHealth performs a single Check, or reads the first Watch update
+func callGRPCHealth(endpoint *models.Endpoint, timeout time.Duration) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	unknown := healthpb.HealthCheckResponse_UNKNOWN
+
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return unknown, fmt.Errorf("invalid URL: %v", err)
+	}
+
+	creds := insecure.NewCredentials()
+	if u.Scheme == "grpcs" {
+		tlsConfig, err := buildTLSConfig(endpoint.TLS)
+		if err != nil {
+			return unknown, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return unknown, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := healthpb.NewHealthClient(conn)
+	req := &healthpb.HealthCheckRequest{}
+	if endpoint.GRPC != nil {
+		req.Service = endpoint.GRPC.Service
+	}
+
+	if endpoint.GRPC != nil && endpoint.GRPC.UseWatch {
+		stream, err := client.Watch(ctx, req)
+		if err != nil {
+			return unknown, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return unknown, err
+		}
+		return resp.GetStatus(), nil
+	}
+
+	resp, err := client.Check(ctx, req, grpc.WaitForReady(false))
+	if err != nil {
+		return unknown, err
+	}
+	return resp.GetStatus(), nil
+}
@@ -0,0 +1,130 @@
+package monitor
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"health-caretaker/internal/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer serves the standard health service on a loopback port.
+// The whole server is SERVING, "orders" is SERVING and "billing" NOT_SERVING.
+func startHealthServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("billing", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return "grpc://" + listener.Addr().String()
+}
+
+// unusedAddress returns a loopback address nothing listens on
+func unusedAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func TestCheckGRPC(t *testing.T) {
+	server := startHealthServer(t)
+
+	tests := []struct {
+		name   string
+		url    string
+		probe  *models.GRPCProbe
+		result string
+		code   string
+		status string
+		err    string
+	}{
+		{
+			name:   "server SERVING",
+			url:    server,
+			result: "up",
+			code:   "OK",
+			status: "SERVING",
+		},
+		{
+			name:   "service SERVING",
+			url:    server,
+			probe:  &models.GRPCProbe{Service: "orders"},
+			result: "up",
+			code:   "OK",
+			status: "SERVING",
+		},
+		{
+			name:   "service NOT_SERVING",
+			url:    server,
+			probe:  &models.GRPCProbe{Service: "billing"},
+			result: "down",
+			code:   "OK",
+			status: "NOT_SERVING",
+			err:    "gRPC health status NOT_SERVING",
+		},
+		{
+			name:   "service NOT_SERVING with Watch",
+			url:    server,
+			probe:  &models.GRPCProbe{Service: "billing", UseWatch: true},
+			result: "down",
+			code:   "OK",
+			status: "NOT_SERVING",
+			err:    "gRPC health status NOT_SERVING",
+		},
+		{
+			name:   "unknown service",
+			url:    server,
+			probe:  &models.GRPCProbe{Service: "search"},
+			result: "down",
+			code:   "NotFound",
+			err:    "unknown service",
+		},
+		{
+			name:   "unreachable",
+			url:    "grpc://" + unusedAddress(t),
+			result: "down",
+			code:   "Unavailable",
+			err:    "connection refused",
+		},
+	}
+
+	m := NewMonitor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &models.Endpoint{ID: "grpc", URL: tt.url, Timeout: 2, GRPC: tt.probe}
+			m.checkGRPC(endpoint)
+
+			if endpoint.LastResult != tt.result {
+				t.Errorf("result = %q (%s), want %q", endpoint.LastResult, endpoint.Error, tt.result)
+			}
+			if endpoint.GRPCCode != tt.code {
+				t.Errorf("code = %q, want %q", endpoint.GRPCCode, tt.code)
+			}
+			if endpoint.GRPCStatus != tt.status {
+				t.Errorf("status = %q, want %q", endpoint.GRPCStatus, tt.status)
+			}
+			if tt.err == "" && endpoint.Error != "" || !strings.Contains(endpoint.Error, tt.err) {
+				t.Errorf("error = %q, want %q", endpoint.Error, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultHistorySize is the number of results kept per endpoint when none is configured
+const DefaultHistorySize = 1000
+
+// historyBuffer is a fixed-size ring buffer of check results
+type historyBuffer struct {
+	results []models.CheckResult
+	start   int
+	count   int
+}
+
+// newHistoryBuffer creates an empty buffer holding up to size results
+func newHistoryBuffer(size int) *historyBuffer {
+	return &historyBuffer{results: make([]models.CheckResult, size)}
+}
+
+// add appends a result, overwriting the oldest one when the buffer is full
+func (h *historyBuffer) add(result models.CheckResult) {
+	size := len(h.results)
+	if h.count < size {
+		h.results[(h.start+h.count)%size] = result
+		h.count++
+		return
+	}
+	h.results[h.start] = result
+	h.start = (h.start + 1) % size
+}
+
+// query returns results within [from, to], oldest first, keeping only the
+// newest limit entries. Zero times and a zero limit are unbounded.
+func (h *historyBuffer) query(from, to time.Time, limit int) []models.CheckResult {
+	results := make([]models.CheckResult, 0, h.count)
+	for i := 0; i < h.count; i++ {
+		result := h.results[(h.start+i)%len(h.results)]
+		if !from.IsZero() && result.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && result.Timestamp.After(to) {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	if limit > 0 && len(results) > limit {
+		results = results[len(results)-limit:]
+	}
+	return results
+}
+
+// resize changes the capacity, keeping the newest results
+func (h *historyBuffer) resize(size int) {
+	results := h.query(time.Time{}, time.Time{}, size)
+	h.results = make([]models.CheckResult, size)
+	h.start = 0
+	h.count = copy(h.results, results)
+}
+
+// SetHistorySize sets how many check results are kept per endpoint
+func (m *Monitor) SetHistorySize(size int) {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+
+	m.historyMutex.Lock()
+	defer m.historyMutex.Unlock()
+
+	m.historySize = size
+	for _, buffer := range m.history {
+		buffer.resize(size)
+	}
+}
+
+// GetHistory returns the check results of an endpoint within [from, to],
+// oldest first, limited to the newest limit entries
+func (m *Monitor) GetHistory(id string, from, to time.Time, limit int) []models.CheckResult {
+	m.historyMutex.RLock()
+	defer m.historyMutex.RUnlock()
+
+	buffer, exists := m.history[id]
+	if !exists {
+		return []models.CheckResult{}
+	}
+	return buffer.query(from, to, limit)
+}
+
+// Results returns the check results of an endpoint within [from, to], oldest
+// first. Results come from the store when one is configured, so they reach
+// further back than the in-memory history.
+func (m *Monitor) Results(id string, from, to time.Time) ([]models.CheckResult, error) {
+	if m.store != nil {
+		return m.store.Results(id, from, to, 0)
+	}
+	return m.GetHistory(id, from, to, 0), nil
+}
+
+// recordResult appends the latest check of an endpoint to its history
+func (m *Monitor) recordResult(endpoint *models.Endpoint) {
+	result := models.CheckResult{
+		Timestamp:    endpoint.LastCheck,
+		Status:       endpoint.LastResult,
+		StatusCode:   endpoint.StatusCode,
+		ResponseTime: endpoint.ResponseTime,
+		Error:        endpoint.Error,
+		Maintenance:  endpoint.Status == "maintenance",
+	}
+
+	m.historyMutex.Lock()
+	buffer, exists := m.history[endpoint.ID]
+	if !exists {
+		buffer = newHistoryBuffer(m.historySize)
+		m.history[endpoint.ID] = buffer
+	}
+	buffer.add(result)
+	m.historyMutex.Unlock()
+
+	m.persistResult(endpoint.ID, result)
+}
+
+// removeHistory drops the history of a removed endpoint
+func (m *Monitor) removeHistory(id string) {
+	m.historyMutex.Lock()
+	defer m.historyMutex.Unlock()
+	delete(m.history, id)
+}
@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"health-caretaker/internal/assertions"
+	"health-caretaker/internal/models"
+)
+
+// checkHTTP performs an HTTP(S) request against the endpoint URL
+func (m *Monitor) checkHTTP(endpoint *models.Endpoint) {
+	start := time.Now()
+	endpoint.TLSCert = nil
+	endpoint.AssertionResults = nil
+
+	tlsConfig, err := buildTLSConfig(endpoint.TLS)
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		endpoint.LastCheck = time.Now()
+		return
+	}
+
+	// Create HTTP client with timeout. Every check opens a fresh connection,
+	// so that each one measures the full connection setup, and closes it
+	// afterwards instead of leaving it idle in a transport nobody reuses.
+	transport := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Timeout:   time.Duration(endpoint.Timeout) * time.Second,
+		Transport: transport,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
+	defer cancel()
+
+	// Create request
+	req, err := m.buildRequest(ctx, endpoint)
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = fmt.Sprintf("Failed to create request: %v", err)
+		endpoint.LastCheck = time.Now()
+		return
+	}
+
+	// Perform request
+	resp, err := client.Do(req)
+	responseTime := time.Since(start).Milliseconds()
+
+	endpoint.LastCheck = time.Now()
+	endpoint.ResponseTime = responseTime
+
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		endpoint.StatusCode = 0
+	} else {
+		endpoint.StatusCode = resp.StatusCode
+		endpoint.Error = ""
+
+		results := evaluateResponse(endpoint, resp)
+		if message, failed := assertions.FirstFailure(results); failed {
+			endpoint.LastResult = "down"
+			endpoint.Error = message
+		} else {
+			endpoint.LastResult = "up"
+		}
+
+		endpoint.TLSCert = inspectTLS(endpoint, resp.TLS)
+		applyCertExpiry(endpoint)
+
+		resp.Body.Close()
+	}
+}
+
+// evaluateResponse reads the body when assertions need it and records the
+// per-assertion results on the endpoint
+func evaluateResponse(endpoint *models.Endpoint, resp *http.Response) []models.AssertionResult {
+	var body []byte
+	tooLarge := false
+
+	if assertions.NeedsBody(endpoint.Assertions) {
+		limit := assertions.BodyLimit(endpoint.Assertions)
+		data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+		if err != nil {
+			return []models.AssertionResult{{Name: "body", Message: fmt.Sprintf("Failed to read body: %v", err)}}
+		}
+		if int64(len(data)) > limit {
+			tooLarge = true
+			data = data[:limit]
+		}
+		body = data
+	}
+
+	results := assertions.Evaluate(endpoint.Assertions, resp.StatusCode, resp.Header, body, tooLarge)
+	if endpoint.Assertions != nil {
+		endpoint.AssertionResults = results
+	}
+	return results
+}
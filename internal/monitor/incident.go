@@ -0,0 +1,307 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// maxResolvedIncidents bounds the resolved incidents kept in memory without a store
+const maxResolvedIncidents = 500
+
+// incidentMessage wraps an incident sent over the WebSocket, setting it apart
+// from endpoint updates
+type incidentMessage struct {
+	Type     string           `json:"type"` // Always "incident"
+	Incident *models.Incident `json:"incident"`
+}
+
+// trackIncident opens an incident when an endpoint goes down, updates it
+// while the endpoint stays down and resolves it on recovery. Opening and
+// resolving an incident are reported to the transition callback.
+func (m *Monitor) trackIncident(endpoint *models.Endpoint, previous string) {
+	m.incidentMutex.Lock()
+
+	incident, open := m.incidents[endpoint.ID]
+	notify := false
+	switch {
+	case endpoint.Status == "down" && !open:
+		incident = &models.Incident{
+			ID:           fmt.Sprintf("incident_%d", time.Now().UnixNano()),
+			EndpointID:   endpoint.ID,
+			EndpointName: endpoint.Name,
+			URL:          endpoint.URL,
+			Labels:       copyLabels(endpoint.Labels),
+			StartedAt:    endpoint.LastCheck,
+			FirstError:   endpoint.Error,
+			LastError:    endpoint.Error,
+			CheckCount:   1,
+		}
+		incident.AddEvent(endpoint.LastCheck, models.EventOpened, endpoint.Error, "")
+		m.incidents[endpoint.ID] = incident
+		endpoint.IncidentID = incident.ID
+		endpoint.AckedBy = ""
+		notify = true
+	case endpoint.Status == "down":
+		incident.CheckCount++
+		if endpoint.Error != "" && endpoint.Error != incident.LastError {
+			incident.LastError = endpoint.Error
+			incident.AddEvent(endpoint.LastCheck, models.EventError, endpoint.Error, "")
+		}
+	case open && endpoint.Status != "checking":
+		resolved := endpoint.LastCheck
+		incident.ResolvedAt = &resolved
+		incident.AddEvent(resolved, models.EventResolved, "Endpoint is "+endpoint.Status, "")
+		delete(m.incidents, endpoint.ID)
+		endpoint.IncidentID = ""
+		endpoint.AckedBy = ""
+		m.keepResolved(incident)
+		notify = true
+	default:
+		m.incidentMutex.Unlock()
+		return
+	}
+
+	saved := incident.Copy()
+	m.incidentMutex.Unlock()
+
+	m.persistIncident(saved)
+	m.broadcastIncident(saved)
+
+	if notify && m.transitionCallback != nil {
+		if previous == "" {
+			previous = "checking"
+		}
+		m.transitionCallback(models.Transition{
+			EndpointID:   endpoint.ID,
+			EndpointName: endpoint.Name,
+			URL:          endpoint.URL,
+			Labels:       endpoint.Labels,
+			From:         previous,
+			To:           endpoint.Status,
+			StatusCode:   endpoint.StatusCode,
+			Error:        endpoint.Error,
+			Time:         endpoint.LastCheck,
+			DownSince:    saved.StartedAt,
+			IncidentID:   saved.ID,
+		})
+	}
+}
+
+// keepResolved remembers a resolved incident when there is no store to hold
+// it; the caller must hold the incident mutex
+func (m *Monitor) keepResolved(incident *models.Incident) {
+	if m.store != nil {
+		return
+	}
+	m.resolved = append(m.resolved, incident)
+	if len(m.resolved) > maxResolvedIncidents {
+		m.resolved = m.resolved[len(m.resolved)-maxResolvedIncidents:]
+	}
+}
+
+// persistIncident saves an incident if persistence is enabled
+func (m *Monitor) persistIncident(incident *models.Incident) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.SaveIncident(incident); err != nil {
+		log.Printf("Error saving incident %s: %v", incident.ID, err)
+	}
+}
+
+// broadcastIncident sends an incident to all connected WebSocket clients
+func (m *Monitor) broadcastIncident(incident *models.Incident) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	message, err := json.Marshal(incidentMessage{Type: "incident", Incident: incident})
+	if err != nil {
+		log.Printf("Error marshaling incident update: %v", err)
+		return
+	}
+	m.broadcast(message)
+}
+
+// restoreIncidents reloads the open incidents of the given endpoints
+func (m *Monitor) restoreIncidents(endpoints []*models.Endpoint) error {
+	incidents, err := m.store.Incidents()
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		known[endpoint.ID] = true
+	}
+
+	m.incidentMutex.Lock()
+	defer m.incidentMutex.Unlock()
+
+	for _, incident := range incidents {
+		if incident.IsOpen() && known[incident.EndpointID] {
+			m.incidents[incident.EndpointID] = incident
+		}
+	}
+	for _, endpoint := range endpoints {
+		if incident, open := m.incidents[endpoint.ID]; open {
+			endpoint.IncidentID = incident.ID
+			endpoint.AckedBy = incident.AckedBy
+		}
+	}
+	return nil
+}
+
+// GetIncidents returns all known incidents, most recently started first.
+// Without a store only open and recently resolved incidents are known.
+func (m *Monitor) GetIncidents() ([]*models.Incident, error) {
+	var incidents []*models.Incident
+	if m.store != nil {
+		stored, err := m.store.Incidents()
+		if err != nil {
+			return nil, err
+		}
+		incidents = stored
+	} else {
+		m.incidentMutex.Lock()
+		for _, incident := range m.incidents {
+			incidents = append(incidents, incident.Copy())
+		}
+		for _, incident := range m.resolved {
+			incidents = append(incidents, incident.Copy())
+		}
+		m.incidentMutex.Unlock()
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartedAt.After(incidents[j].StartedAt)
+	})
+	return incidents, nil
+}
+
+// GetIncident returns an incident by ID
+func (m *Monitor) GetIncident(id string) (*models.Incident, bool) {
+	m.incidentMutex.Lock()
+	defer m.incidentMutex.Unlock()
+
+	incident := m.findIncident(id)
+	if incident == nil {
+		return nil, false
+	}
+	return incident.Copy(), true
+}
+
+// findIncident looks an incident up among the open ones, then the resolved
+// ones; the caller must hold the incident mutex
+func (m *Monitor) findIncident(id string) *models.Incident {
+	for _, incident := range m.incidents {
+		if incident.ID == id {
+			return incident
+		}
+	}
+	if m.store != nil {
+		incident, err := m.store.Incident(id)
+		if err != nil {
+			log.Printf("Error loading incident %s: %v", id, err)
+		}
+		return incident
+	}
+	for _, incident := range m.resolved {
+		if incident.ID == id {
+			return incident
+		}
+	}
+	return nil
+}
+
+// AddIncidentNote appends a note to an open or resolved incident. It returns
+// false if the incident does not exist.
+func (m *Monitor) AddIncidentNote(id, author, text string) (*models.Incident, bool) {
+	m.incidentMutex.Lock()
+	incident := m.findIncident(id)
+	if incident == nil {
+		m.incidentMutex.Unlock()
+		return nil, false
+	}
+	now := time.Now()
+	incident.Notes = append(incident.Notes, models.IncidentNote{Time: now, Author: author, Text: text})
+	incident.AddEvent(now, models.EventNote, text, author)
+	saved := incident.Copy()
+	m.incidentMutex.Unlock()
+
+	m.persistIncident(saved)
+	m.broadcastIncident(saved)
+	return saved, true
+}
+
+// AcknowledgeIncident marks the open incident of an endpoint as acknowledged,
+// which stops repeat reminders. The first acknowledgement is also passed to
+// the transition callback, so that receivers can acknowledge the incidents
+// they opened. It returns false if the endpoint has no open incident.
+func (m *Monitor) AcknowledgeIncident(endpointID, by string) (*models.Incident, bool) {
+	m.incidentMutex.Lock()
+	incident, open := m.incidents[endpointID]
+	if !open {
+		m.incidentMutex.Unlock()
+		return nil, false
+	}
+	first := incident.AckedAt == nil
+	now := time.Now()
+	incident.AckedBy = by
+	incident.AckedAt = &now
+	incident.AddEvent(now, models.EventAcknowledged, "", by)
+	saved := incident.Copy()
+	m.incidentMutex.Unlock()
+
+	m.persistIncident(saved)
+	m.broadcastIncident(saved)
+
+	if endpoint, exists := m.GetEndpoint(endpointID); exists {
+		endpoint.AckedBy = by
+		m.broadcastUpdate(endpoint)
+
+		if first && m.transitionCallback != nil {
+			m.transitionCallback(models.Transition{
+				EndpointID:   endpoint.ID,
+				EndpointName: endpoint.Name,
+				URL:          endpoint.URL,
+				Labels:       endpoint.Labels,
+				From:         "down",
+				To:           "down",
+				StatusCode:   endpoint.StatusCode,
+				Error:        endpoint.Error,
+				Time:         now,
+				DownSince:    saved.StartedAt,
+				IncidentID:   saved.ID,
+				Acknowledged: true,
+				AckedBy:      by,
+			})
+		}
+	}
+	return saved, true
+}
+
+// IsAcknowledged reports whether the endpoint's open incident was acknowledged
+func (m *Monitor) IsAcknowledged(endpointID string) bool {
+	m.incidentMutex.Lock()
+	defer m.incidentMutex.Unlock()
+
+	incident, open := m.incidents[endpointID]
+	return open && incident.AckedAt != nil
+}
+
+// copyLabels returns a copy of an endpoint's labels
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	c := make(map[string]string, len(labels))
+	for name, value := range labels {
+		c[name] = value
+	}
+	return c
+}
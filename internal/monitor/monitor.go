@@ -2,33 +2,54 @@ package monitor
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"sync"
 	"time"
 
+	"health-caretaker/internal/maintenance"
 	"health-caretaker/internal/models"
+	"health-caretaker/internal/storage"
 
 	"github.com/gorilla/websocket"
 )
 
 // Monitor manages endpoint monitoring
 type Monitor struct {
-	endpoints       map[string]*models.Endpoint
-	clients         map[*websocket.Conn]bool
-	upgrader        websocket.Upgrader
-	mutex           sync.RWMutex
-	metricsCallback func(*models.Endpoint) // Callback for metrics updates
+	endpoints          map[string]*models.Endpoint
+	clients            map[*websocket.Conn]bool
+	upgrader           websocket.Upgrader
+	mutex              sync.RWMutex
+	metricsCallback    func(*models.Endpoint)  // Callback for metrics updates
+	transitionCallback func(models.Transition) // Callback for up/down transitions
+	tokens             map[string]*tokenCache  // Cached OAuth2 tokens by token URL, client and scopes
+	tokenMutex         sync.Mutex
+	scheduler          *scheduler
+	pool               *probePool
+	history            map[string]*historyBuffer // Recent check results per endpoint
+	historySize        int
+	historyMutex       sync.RWMutex
+	store              storage.Store               // Optional persistence backend
+	retention          time.Duration               // How long stored results are kept
+	incidents          map[string]*models.Incident // Open incidents by endpoint ID
+	resolved           []*models.Incident          // Recently resolved incidents, kept without a store
+	maintenance        *maintenance.Windows        // Optional maintenance windows
+	incidentMutex      sync.Mutex
 }
 
 // NewMonitor creates a new monitor instance
 func NewMonitor() *Monitor {
 	return &Monitor{
-		endpoints: make(map[string]*models.Endpoint),
-		clients:   make(map[*websocket.Conn]bool),
+		endpoints:   make(map[string]*models.Endpoint),
+		clients:     make(map[*websocket.Conn]bool),
+		tokens:      make(map[string]*tokenCache),
+		scheduler:   newScheduler(),
+		pool:        newProbePool(),
+		history:     make(map[string]*historyBuffer),
+		historySize: DefaultHistorySize,
+		incidents:   make(map[string]*models.Incident),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -37,34 +58,54 @@ func NewMonitor() *Monitor {
 	}
 }
 
+// SetMaintenance sets the maintenance windows applied to checks
+func (m *Monitor) SetMaintenance(windows *maintenance.Windows) {
+	m.maintenance = windows
+}
+
 // SetMetricsCallback sets the callback function for metrics updates
 func (m *Monitor) SetMetricsCallback(callback func(*models.Endpoint)) {
 	m.metricsCallback = callback
 }
 
-// AddEndpoint adds a new endpoint to monitor
-func (m *Monitor) AddEndpoint(endpoint *models.Endpoint) {
+// SetTransitionCallback sets the callback invoked when an endpoint goes down
+// or recovers. It is called from the checking goroutine and must not block.
+func (m *Monitor) SetTransitionCallback(callback func(models.Transition)) {
+	m.transitionCallback = callback
+}
+
+// SetPoolConfig sets the probe concurrency limits
+func (m *Monitor) SetPoolConfig(config PoolConfig) {
+	m.pool.setConfig(config)
+}
+
+// PoolStats returns the current probe pool utilisation
+func (m *Monitor) PoolStats() models.PoolStats {
+	return m.pool.stats()
+}
+
+// ErrDuplicateID is returned when adding an endpoint whose ID is already in use
+var ErrDuplicateID = errors.New("endpoint id is already in use")
+
+// AddEndpoint adds a new endpoint to monitor. An endpoint without an ID gets
+// one derived from its name and URL.
+func (m *Monitor) AddEndpoint(endpoint *models.Endpoint) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	if endpoint.ID == "" {
-		endpoint.ID = fmt.Sprintf("endpoint_%d", time.Now().UnixNano())
-	}
-
-	if endpoint.Method == "" {
-		endpoint.Method = "GET"
+		endpoint.ID = models.EndpointID(endpoint.Name, endpoint.URL)
 	}
-
-	if endpoint.Interval == 0 {
-		endpoint.Interval = 30
-	}
-
-	if endpoint.Timeout == 0 {
-		endpoint.Timeout = 10
+	if _, exists := m.endpoints[endpoint.ID]; exists {
+		return ErrDuplicateID
 	}
 
+	applyDefaults(endpoint)
 	endpoint.Status = "checking"
 	m.endpoints[endpoint.ID] = endpoint
+	m.scheduler.add(endpoint.ID, endpoint.IntervalDuration())
+	m.persistEndpoint(endpoint)
+	return nil
 }
 
 // RemoveEndpoint removes an endpoint from monitoring
@@ -72,6 +113,13 @@ func (m *Monitor) RemoveEndpoint(id string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	delete(m.endpoints, id)
+	m.scheduler.remove(id)
+	m.removeHistory(id)
+	m.forgetEndpoint(id)
+
+	m.incidentMutex.Lock()
+	delete(m.incidents, id)
+	m.incidentMutex.Unlock()
 }
 
 // GetEndpoints returns all monitored endpoints
@@ -95,52 +143,50 @@ func (m *Monitor) GetEndpoint(id string) (*models.Endpoint, bool) {
 	return endpoint, exists
 }
 
-// CheckEndpoint performs a health check on a single endpoint
+// CheckEndpoint performs a health check on a single endpoint, retrying
+// failed attempts per the endpoint's retry policy, and updates the visible
+// status once the failure or success threshold is met
 func (m *Monitor) CheckEndpoint(endpoint *models.Endpoint) {
-	start := time.Now()
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: time.Duration(endpoint.Timeout) * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // Allow self-signed certificates
-			},
-		},
-	}
+	var attempts []models.AttemptResult
+	backoff := retryBackoff(endpoint.Retry)
+
+	for attempt := 0; ; attempt++ {
+		m.probe(endpoint)
+		attempts = append(attempts, models.AttemptResult{
+			Time:         endpoint.LastCheck,
+			Status:       endpoint.LastResult,
+			StatusCode:   endpoint.StatusCode,
+			ResponseTime: endpoint.ResponseTime,
+			Error:        endpoint.Error,
+		})
+
+		if endpoint.LastResult != "down" || endpoint.Retry == nil || attempt >= endpoint.Retry.Attempts {
+			break
+		}
 
-	// Create request
-	req, err := http.NewRequest(endpoint.Method, endpoint.URL, nil)
-	if err != nil {
-		endpoint.Status = "down"
-		endpoint.Error = fmt.Sprintf("Failed to create request: %v", err)
-		endpoint.LastCheck = time.Now()
-		return
+		time.Sleep(backoff)
+		backoff = nextBackoff(backoff, endpoint.Retry)
 	}
 
-	// Perform request
-	resp, err := client.Do(req)
-	responseTime := time.Since(start).Milliseconds()
-
-	endpoint.LastCheck = time.Now()
-	endpoint.ResponseTime = responseTime
-
-	if err != nil {
-		endpoint.Status = "down"
-		endpoint.Error = err.Error()
-		endpoint.StatusCode = 0
+	endpoint.Attempts = attempts
+	previous := endpoint.Status
+	window := m.maintenance.Active(endpoint.ID, endpoint.Labels, endpoint.LastCheck)
+	if previous == "maintenance" && window == nil {
+		// Leaving maintenance, the next result applies immediately as for a new endpoint
+		endpoint.Status = "checking"
+	}
+	applyThresholds(endpoint)
+
+	if window != nil {
+		// Checks keep running, but the endpoint is neither alerted on nor
+		// counted against its uptime until the window ends
+		endpoint.Status = "maintenance"
+		endpoint.MaintenanceID = window.ID
+		m.recordResult(endpoint)
 	} else {
-		endpoint.StatusCode = resp.StatusCode
-		endpoint.Error = ""
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-			endpoint.Status = "up"
-		} else {
-			endpoint.Status = "down"
-			endpoint.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
-		}
-
-		resp.Body.Close()
+		endpoint.MaintenanceID = ""
+		m.recordResult(endpoint)
+		m.trackIncident(endpoint, previous)
 	}
 
 	// Update metrics if callback is set
@@ -149,34 +195,57 @@ func (m *Monitor) CheckEndpoint(endpoint *models.Endpoint) {
 	}
 }
 
-// StartMonitoring begins monitoring all endpoints
+// probe runs a single attempt of the probe matching the endpoint kind
+func (m *Monitor) probe(endpoint *models.Endpoint) {
+	switch endpoint.Kind() {
+	case models.ProbeKindTCP:
+		m.checkTCP(endpoint)
+	case models.ProbeKindDNS:
+		m.checkDNS(endpoint)
+	case models.ProbeKindGRPC:
+		m.checkGRPC(endpoint)
+	default:
+		m.checkHTTP(endpoint)
+	}
+}
+
+// StartMonitoring runs scheduled checks until the context is cancelled.
+// Each endpoint has at most one check in flight at a time.
 func (m *Monitor) StartMonitoring(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	if m.store != nil && m.retention > 0 {
+		go m.pruneStore(ctx)
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
+	m.scheduler.run(ctx, func(entry *scheduledCheck) {
+		id, _ := m.scheduler.scheduled(entry)
+		endpoint, exists := m.GetEndpoint(id)
+		if !exists {
+			m.scheduler.done(entry)
 			return
-		case <-ticker.C:
-			m.mutex.RLock()
-			endpoints := make([]*models.Endpoint, 0, len(m.endpoints))
-			for _, endpoint := range m.endpoints {
-				endpoints = append(endpoints, endpoint)
-			}
-			m.mutex.RUnlock()
-
-			for _, endpoint := range endpoints {
-				// Check if it's time to check this endpoint
-				if time.Since(endpoint.LastCheck) >= time.Duration(endpoint.Interval)*time.Second {
-					go func(ep *models.Endpoint) {
-						m.CheckEndpoint(ep)
-						m.broadcastUpdate(ep)
-					}(endpoint)
-				}
-			}
 		}
-	}
+
+		m.pool.submit(endpoint, func() {
+			defer m.scheduler.done(entry)
+
+			// A reload may have replaced or moved the endpoint while the
+			// check was waiting for the pool, but not while it runs
+			entry.check.Lock()
+			defer entry.check.Unlock()
+			id, scheduled := m.scheduler.scheduled(entry)
+			endpoint, exists := m.GetEndpoint(id)
+			if !scheduled || !exists {
+				return
+			}
+			m.CheckEndpoint(endpoint)
+			m.broadcastUpdate(endpoint)
+		})
+	})
+}
+
+// TriggerCheck schedules an immediate check of an endpoint. It returns false
+// if the endpoint is not monitored.
+func (m *Monitor) TriggerCheck(id string) bool {
+	return m.scheduler.trigger(id)
 }
 
 // broadcastUpdate sends endpoint status to all connected WebSocket clients
@@ -184,12 +253,17 @@ func (m *Monitor) broadcastUpdate(endpoint *models.Endpoint) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	message, err := json.Marshal(endpoint)
+	message, err := json.Marshal(endpoint.Redacted())
 	if err != nil {
 		log.Printf("Error marshaling endpoint update: %v", err)
 		return
 	}
+	m.broadcast(message)
+}
 
+// broadcast sends a message to all connected WebSocket clients; the caller
+// must hold the mutex
+func (m *Monitor) broadcast(message []byte) {
 	for client := range m.clients {
 		err := client.WriteMessage(websocket.TextMessage, message)
 		if err != nil {
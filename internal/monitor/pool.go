@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"net/url"
+	"sync"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultMaxConcurrent is the global probe limit used when none is configured
+const DefaultMaxConcurrent = 100
+
+// PoolConfig limits how many probes run at the same time
+type PoolConfig struct {
+	MaxConcurrent int            // Total concurrent probes
+	PerHost       int            // Concurrent probes per target host; 0 means unlimited
+	GroupLabel    string         // Endpoint label that assigns probes to groups, e.g. "team"
+	PerGroup      int            // Default concurrent probes per group; 0 means unlimited
+	GroupLimits   map[string]int // Per-group overrides keyed by label value
+}
+
+// poolJob is a probe waiting for, or holding, a slot in the pool
+type poolJob struct {
+	host  string
+	group string
+	run   func()
+}
+
+// probePool admits probes under the global, per-host and per-group limits.
+// Jobs blocked by a host or group limit do not hold up jobs for other hosts
+// or groups.
+type probePool struct {
+	mutex        sync.Mutex
+	config       PoolConfig
+	running      int
+	hostRunning  map[string]int
+	groupRunning map[string]int
+	pending      []*poolJob
+}
+
+// newProbePool creates a pool with the default global limit
+func newProbePool() *probePool {
+	return &probePool{
+		config:       PoolConfig{MaxConcurrent: DefaultMaxConcurrent},
+		hostRunning:  make(map[string]int),
+		groupRunning: make(map[string]int),
+	}
+}
+
+// setConfig replaces the pool limits; running probes are not interrupted
+func (p *probePool) setConfig(config PoolConfig) {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = DefaultMaxConcurrent
+	}
+
+	p.mutex.Lock()
+	p.config = config
+	p.mutex.Unlock()
+
+	p.pump()
+}
+
+// submit queues a probe for the endpoint; it never blocks
+func (p *probePool) submit(endpoint *models.Endpoint, run func()) {
+	job := &poolJob{run: run}
+	if u, err := url.Parse(endpoint.URL); err == nil {
+		job.host = u.Hostname()
+	}
+
+	p.mutex.Lock()
+	if p.config.GroupLabel != "" {
+		job.group = endpoint.Labels[p.config.GroupLabel]
+	}
+	p.pending = append(p.pending, job)
+	p.mutex.Unlock()
+
+	p.pump()
+}
+
+// pump starts every pending job that fits within the limits, in FIFO order
+func (p *probePool) pump() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	remaining := p.pending[:0]
+	for i, job := range p.pending {
+		if p.running >= p.config.MaxConcurrent {
+			remaining = append(remaining, p.pending[i:]...)
+			break
+		}
+		if !p.admitLocked(job) {
+			remaining = append(remaining, job)
+			continue
+		}
+
+		p.running++
+		p.hostRunning[job.host]++
+		p.groupRunning[job.group]++
+		go p.execute(job)
+	}
+
+	for i := len(remaining); i < len(p.pending); i++ {
+		p.pending[i] = nil
+	}
+	p.pending = remaining
+}
+
+// admitLocked reports whether the job's host and group have a free slot
+func (p *probePool) admitLocked(job *poolJob) bool {
+	if p.config.PerHost > 0 && p.hostRunning[job.host] >= p.config.PerHost {
+		return false
+	}
+
+	if job.group != "" {
+		limit := p.config.PerGroup
+		if override, ok := p.config.GroupLimits[job.group]; ok {
+			limit = override
+		}
+		if limit > 0 && p.groupRunning[job.group] >= limit {
+			return false
+		}
+	}
+
+	return true
+}
+
+// execute runs a job and releases its slots afterwards
+func (p *probePool) execute(job *poolJob) {
+	defer func() {
+		p.mutex.Lock()
+		p.running--
+		if p.hostRunning[job.host]--; p.hostRunning[job.host] <= 0 {
+			delete(p.hostRunning, job.host)
+		}
+		if p.groupRunning[job.group]--; p.groupRunning[job.group] <= 0 {
+			delete(p.groupRunning, job.group)
+		}
+		p.mutex.Unlock()
+
+		p.pump()
+	}()
+
+	job.run()
+}
+
+// stats returns a snapshot of the pool utilisation
+func (p *probePool) stats() models.PoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := models.PoolStats{
+		Running:    p.running,
+		Queued:     len(p.pending),
+		Capacity:   p.config.MaxConcurrent,
+		GroupLabel: p.config.GroupLabel,
+	}
+
+	if p.config.GroupLabel != "" {
+		stats.GroupRunning = make(map[string]int, len(p.groupRunning))
+		for group, running := range p.groupRunning {
+			if group != "" {
+				stats.GroupRunning[group] = running
+			}
+		}
+		stats.GroupQueued = make(map[string]int)
+		for _, job := range p.pending {
+			if job.group != "" {
+				stats.GroupQueued[job.group]++
+			}
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,206 @@
+package monitor
+
+import (
+	"log"
+	"reflect"
+
+	"health-caretaker/internal/models"
+)
+
+// ReloadResult summarises how a configuration reload changed the endpoints
+type ReloadResult struct {
+	Added     []*models.Endpoint
+	Updated   []*models.Endpoint
+	Removed   []*models.Endpoint
+	Renamed   map[string]string // New ID by old ID of the updated endpoints whose ID changed
+	Unchanged int
+}
+
+// ApplyConfig brings the endpoints defined in the configuration in line with
+// a newly loaded configuration. Endpoints are matched by ID: new ones are
+// added, ones no longer configured are removed, and changed ones are replaced
+// under their ID, keeping their status, history and open incident. Endpoints
+// added through the API are left alone unless a configured endpoint takes
+// over their ID.
+//
+// A configured endpoint with a new ID, as derived from an edited name or URL,
+// is matched with a configured endpoint whose ID is no longer configured by
+// name, or else by URL, and takes over its state under the new ID.
+//
+// An endpoint whose check is running is replaced once the check finished, so
+// that its result is kept and the check is not run twice at once.
+func (m *Monitor) ApplyConfig(configured []*models.Endpoint) ReloadResult {
+	result := ReloadResult{Renamed: make(map[string]string)}
+
+	running := make(map[string]*models.Endpoint)
+	for _, endpoint := range m.GetEndpoints() {
+		running[endpoint.ID] = endpoint
+	}
+
+	// Running config endpoints whose ID is no longer configured may have
+	// changed their ID
+	ids := make(map[string]bool, len(configured))
+	for _, endpoint := range configured {
+		ids[endpoint.ID] = true
+	}
+	byName := make(map[string]*models.Endpoint)
+	byURL := make(map[string]*models.Endpoint)
+	for _, endpoint := range running {
+		if endpoint.Source == models.SourceConfig && !ids[endpoint.ID] {
+			byName[endpoint.Name] = endpoint
+			byURL[endpoint.URL] = endpoint
+		}
+	}
+
+	for _, endpoint := range configured {
+		old, exists := running[endpoint.ID]
+		if !exists {
+			if old = byName[endpoint.Name]; old == nil {
+				old = byURL[endpoint.URL]
+			}
+			if old == nil || running[old.ID] == nil {
+				if err := m.AddEndpoint(endpoint); err != nil {
+					log.Printf("Error adding endpoint %s: %v", endpoint.ID, err)
+					continue
+				}
+				result.Added = append(result.Added, endpoint)
+				continue
+			}
+		}
+		delete(running, old.ID)
+
+		entry := m.scheduler.hold(old.ID)
+		updated, err := m.updateEndpoint(old, endpoint)
+		m.scheduler.release(entry)
+		if err != nil {
+			log.Printf("Error moving endpoint %s to ID %s: %v", old.ID, endpoint.ID, err)
+			continue
+		}
+		if old.ID != endpoint.ID {
+			result.Renamed[old.ID] = endpoint.ID
+		}
+		if updated {
+			result.Updated = append(result.Updated, endpoint)
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	for _, endpoint := range running {
+		if endpoint.Source != models.SourceConfig {
+			continue
+		}
+		m.RemoveEndpoint(endpoint.ID)
+		result.Removed = append(result.Removed, endpoint)
+	}
+
+	return result
+}
+
+// updateEndpoint replaces a monitored endpoint with its new definition,
+// moving it first if its ID changed, and reports whether the definition
+// changed. The caller holds the endpoint's scheduler entry.
+func (m *Monitor) updateEndpoint(old, endpoint *models.Endpoint) (bool, error) {
+	if old.ID != endpoint.ID {
+		if err := m.moveEndpoint(old.ID, endpoint.ID); err != nil {
+			return false, err
+		}
+	}
+
+	applyDefaults(endpoint)
+	copyState(endpoint, old)
+	if reflect.DeepEqual(endpoint, old) {
+		return false, nil
+	}
+	m.replaceEndpoint(old, endpoint)
+	return true, nil
+}
+
+// replaceEndpoint swaps the definition of a monitored endpoint, rescheduling
+// it when its interval changed
+func (m *Monitor) replaceEndpoint(old, endpoint *models.Endpoint) {
+	m.mutex.Lock()
+	m.endpoints[endpoint.ID] = endpoint
+	m.mutex.Unlock()
+
+	if endpoint.Interval != old.Interval {
+		m.scheduler.add(endpoint.ID, endpoint.IntervalDuration())
+	}
+	m.persistEndpoint(endpoint)
+	m.broadcastUpdate(endpoint)
+}
+
+// moveEndpoint moves the history, open incident and stored state of a
+// monitored endpoint to a new ID, along with its scheduler entry. The caller
+// then replaces the endpoint under the new ID.
+func (m *Monitor) moveEndpoint(oldID, newID string) error {
+	if m.store != nil {
+		if err := m.store.RenameEndpoint(oldID, newID); err != nil {
+			return err
+		}
+	}
+
+	m.mutex.Lock()
+	delete(m.endpoints, oldID)
+	m.scheduler.rename(oldID, newID)
+	m.mutex.Unlock()
+
+	m.historyMutex.Lock()
+	if buffer, exists := m.history[oldID]; exists {
+		m.history[newID] = buffer
+		delete(m.history, oldID)
+	}
+	m.historyMutex.Unlock()
+
+	m.incidentMutex.Lock()
+	if incident, open := m.incidents[oldID]; open {
+		incident.EndpointID = newID
+		m.incidents[newID] = incident
+		delete(m.incidents, oldID)
+	}
+	for _, incident := range m.resolved {
+		if incident.EndpointID == oldID {
+			incident.EndpointID = newID
+		}
+	}
+	m.incidentMutex.Unlock()
+
+	log.Printf("Moved endpoint %s to ID %s", oldID, newID)
+	return nil
+}
+
+// applyDefaults fills the settings AddEndpoint defaults when they are unset
+func applyDefaults(endpoint *models.Endpoint) {
+	if endpoint.Method == "" {
+		endpoint.Method = "GET"
+	}
+	if endpoint.Interval <= 0 {
+		endpoint.Interval = 30
+	}
+	if endpoint.Timeout == 0 {
+		endpoint.Timeout = 10
+	}
+}
+
+// copyState copies the results of previous checks, leaving the definition alone
+func copyState(dst, src *models.Endpoint) {
+	dst.LastCheck = src.LastCheck
+	dst.Status = src.Status
+	dst.LastResult = src.LastResult
+	dst.ConsecutiveFailures = src.ConsecutiveFailures
+	dst.ConsecutiveSuccesses = src.ConsecutiveSuccesses
+	dst.Attempts = src.Attempts
+	dst.StatusCode = src.StatusCode
+	dst.ResponseTime = src.ResponseTime
+	dst.ConnectTime = src.ConnectTime
+	dst.DNSRcode = src.DNSRcode
+	dst.DNSAnswers = src.DNSAnswers
+	dst.TLSCert = src.TLSCert
+	dst.GRPCCode = src.GRPCCode
+	dst.GRPCStatus = src.GRPCStatus
+	dst.AssertionResults = src.AssertionResults
+	dst.Error = src.Error
+	dst.IncidentID = src.IncidentID
+	dst.AckedBy = src.AckedBy
+	dst.MaintenanceID = src.MaintenanceID
+}
@@ -0,0 +1,216 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/storage"
+)
+
+func configEndpoint(name, url string) *models.Endpoint {
+	return &models.Endpoint{
+		ID:       models.EndpointID(name, url),
+		Name:     name,
+		URL:      url,
+		Interval: 30,
+		Source:   models.SourceConfig,
+	}
+}
+
+func TestApplyConfigMovesChangedIDs(t *testing.T) {
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	defer store.Close()
+
+	m := NewMonitor()
+	m.SetStore(store, 0)
+
+	orders := configEndpoint("Orders API", "https://orders.internal/healthz")
+	billing := configEndpoint("Billing", "https://billing.internal/healthz")
+	m.ApplyConfig([]*models.Endpoint{orders, billing})
+
+	// Orders goes down and opens an incident
+	orders.LastCheck = time.Now()
+	orders.LastResult = "down"
+	orders.Status = "down"
+	orders.Error = "connection refused"
+	m.recordResult(orders)
+	m.trackIncident(orders, "up")
+	billing.LastCheck = time.Now()
+	billing.LastResult = "up"
+	billing.Status = "up"
+	m.recordResult(billing)
+
+	// The URL of orders and the name of billing change, and with them their
+	// derived IDs
+	movedOrders := configEndpoint("Orders API", "https://orders.internal/readyz")
+	movedBilling := configEndpoint("Billing service", "https://billing.internal/healthz")
+	result := m.ApplyConfig([]*models.Endpoint{movedOrders, movedBilling})
+
+	if len(result.Added) != 0 || len(result.Removed) != 0 {
+		t.Fatalf("added %d and removed %d endpoints, want none", len(result.Added), len(result.Removed))
+	}
+	if result.Renamed[orders.ID] != movedOrders.ID || result.Renamed[billing.ID] != movedBilling.ID {
+		t.Fatalf("Renamed = %v", result.Renamed)
+	}
+
+	for _, test := range []struct{ old, moved *models.Endpoint }{{orders, movedOrders}, {billing, movedBilling}} {
+		if _, exists := m.GetEndpoint(test.old.ID); exists {
+			t.Errorf("endpoint still monitored under old ID %s", test.old.ID)
+		}
+		endpoint, exists := m.GetEndpoint(test.moved.ID)
+		if !exists {
+			t.Fatalf("endpoint not monitored under new ID %s", test.moved.ID)
+		}
+		if endpoint.Status != test.old.Status {
+			t.Errorf("%s: status = %q, want %q", test.moved.Name, endpoint.Status, test.old.Status)
+		}
+		if history := m.GetHistory(test.moved.ID, time.Time{}, time.Time{}, 0); len(history) != 1 {
+			t.Errorf("%s: %d results in memory, want 1", test.moved.Name, len(history))
+		}
+		stored, err := store.Results(test.moved.ID, time.Time{}, time.Time{}, 0)
+		if err != nil || len(stored) != 1 {
+			t.Errorf("%s: %d stored results (%v), want 1", test.moved.Name, len(stored), err)
+		}
+	}
+
+	if _, open := m.AcknowledgeIncident(movedOrders.ID, "test"); !open {
+		t.Errorf("open incident did not move to the new ID")
+	}
+	incidents, err := m.GetIncidents()
+	if err != nil || len(incidents) != 1 || incidents[0].EndpointID != movedOrders.ID {
+		t.Errorf("incidents = %+v (%v), want one for %s", incidents, err, movedOrders.ID)
+	}
+}
+
+func TestApplyConfigAddsAndRemoves(t *testing.T) {
+	m := NewMonitor()
+	orders := configEndpoint("Orders API", "https://orders.internal/healthz")
+	m.ApplyConfig([]*models.Endpoint{orders})
+
+	search := configEndpoint("Search", "https://search.internal/healthz")
+	result := m.ApplyConfig([]*models.Endpoint{search})
+	if len(result.Added) != 1 || len(result.Removed) != 1 || len(result.Renamed) != 0 {
+		t.Fatalf("added %d, removed %d, renamed %d; want 1, 1, 0", len(result.Added), len(result.Removed), len(result.Renamed))
+	}
+	if _, exists := m.GetEndpoint(orders.ID); exists {
+		t.Errorf("removed endpoint is still monitored")
+	}
+}
+
+func TestApplyConfigUpdatesInPlace(t *testing.T) {
+	m := NewMonitor()
+	orders := configEndpoint("Orders API", "https://orders.internal/healthz")
+	m.ApplyConfig([]*models.Endpoint{orders})
+	orders.Status = "down"
+	orders.ConsecutiveFailures = 3
+
+	slower := configEndpoint("Orders API", "https://orders.internal/healthz")
+	slower.Interval = 60
+	result := m.ApplyConfig([]*models.Endpoint{slower})
+	if len(result.Updated) != 1 || len(result.Added) != 0 || len(result.Removed) != 0 {
+		t.Fatalf("updated %d, added %d, removed %d; want 1, 0, 0", len(result.Updated), len(result.Added), len(result.Removed))
+	}
+
+	endpoint, exists := m.GetEndpoint(orders.ID)
+	if !exists {
+		t.Fatalf("endpoint no longer monitored under ID %s", orders.ID)
+	}
+	if endpoint.Interval != 60 || endpoint.Status != "down" || endpoint.ConsecutiveFailures != 3 {
+		t.Errorf("endpoint = %+v, want the new interval with the previous state", endpoint)
+	}
+
+	if result := m.ApplyConfig([]*models.Endpoint{configEndpoint("Orders API", "https://orders.internal/healthz")}); len(result.Updated) != 1 {
+		t.Errorf("reverting the interval updated %d endpoints, want 1", len(result.Updated))
+	}
+	same := configEndpoint("Orders API", "https://orders.internal/healthz")
+	if result := m.ApplyConfig([]*models.Endpoint{same}); result.Unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", result.Unchanged)
+	}
+}
+
+func TestApplyConfigDuringCheck(t *testing.T) {
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	m := NewMonitor()
+	orders := configEndpoint("Orders", server.URL)
+	orders.Interval = 0.1
+	m.ApplyConfig([]*models.Endpoint{orders})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.StartMonitoring(ctx)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first check did not start")
+	}
+
+	// The reload renames the endpoint and changes its interval while its
+	// first check is still running
+	moved := configEndpoint("Orders API", server.URL)
+	moved.Interval = 0.2
+	reloaded := make(chan ReloadResult)
+	go func() { reloaded <- m.ApplyConfig([]*models.Endpoint{moved}) }()
+	time.Sleep(300 * time.Millisecond)
+	close(release)
+
+	var result ReloadResult
+	select {
+	case result = <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload did not finish after the check")
+	}
+	if result.Renamed[orders.ID] != moved.ID {
+		t.Fatalf("Renamed = %v, want %s moved to %s", result.Renamed, orders.ID, moved.ID)
+	}
+	entry := m.scheduler.hold(moved.ID)
+	endpoint, exists := m.GetEndpoint(moved.ID)
+	if !exists || entry == nil {
+		t.Fatalf("endpoint not monitored under new ID %s", moved.ID)
+	}
+	if endpoint.Interval != 0.2 || endpoint.Status != "up" {
+		t.Errorf("interval = %v, status = %q; want 0.2 with the result of the running check", endpoint.Interval, endpoint.Status)
+	}
+	m.scheduler.release(entry)
+
+	// Later checks run under the new ID, one at a time
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	mutex.Lock()
+	defer mutex.Unlock()
+	if maxRunning != 1 {
+		t.Errorf("%d checks of the endpoint ran at once, want 1", maxRunning)
+	}
+	if history := m.GetHistory(moved.ID, time.Time{}, time.Time{}, 0); len(history) < 2 {
+		t.Errorf("%d results under the new ID, want the first check and later ones", len(history))
+	}
+}
@@ -0,0 +1,267 @@
+package monitor
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// minCheckInterval keeps very small or zero intervals from spinning the scheduler
+	minCheckInterval = 100 * time.Millisecond
+
+	// maxInitialJitter caps how far the first check of an endpoint is delayed
+	maxInitialJitter = 10 * time.Second
+)
+
+// scheduledCheck is an endpoint's position in the check queue
+type scheduledCheck struct {
+	id       string // changes when the endpoint is moved to a new ID
+	next     time.Time
+	interval time.Duration
+	index    int        // position in the heap, -1 while not queued
+	inFlight bool       // a check is running; the entry is re-queued when it finishes
+	removed  bool       // the endpoint was removed while its check was running
+	check    sync.Mutex // held while the check runs and while a reload replaces the endpoint
+}
+
+// checkQueue is a min-heap of scheduled checks ordered by their next run time
+type checkQueue []*scheduledCheck
+
+func (q checkQueue) Len() int           { return len(q) }
+func (q checkQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+func (q checkQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *checkQueue) Push(x interface{}) {
+	entry := x.(*scheduledCheck)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *checkQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// scheduler hands out due endpoint checks in order, guaranteeing at most one
+// in-flight check per endpoint
+type scheduler struct {
+	mutex   sync.Mutex
+	queue   checkQueue
+	entries map[string]*scheduledCheck
+	wake    chan struct{}
+}
+
+// newScheduler creates an empty scheduler
+func newScheduler() *scheduler {
+	return &scheduler{
+		entries: make(map[string]*scheduledCheck),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// add schedules an endpoint, spreading its first check with a random jitter.
+// An endpoint that is already scheduled keeps its entry with the new
+// interval, so that a check in flight is not duplicated; the entry is
+// re-queued with the new interval when the check finishes.
+func (s *scheduler) add(id string, interval time.Duration) {
+	interval = clampInterval(interval)
+
+	jitterRange := interval
+	if jitterRange > maxInitialJitter {
+		jitterRange = maxInitialJitter
+	}
+	next := time.Now().Add(time.Duration(rand.Int63n(int64(jitterRange))))
+
+	s.mutex.Lock()
+	if entry, ok := s.entries[id]; ok {
+		entry.interval = interval
+		if !entry.inFlight {
+			entry.next = next
+			heap.Fix(&s.queue, entry.index)
+		}
+	} else {
+		entry := &scheduledCheck{id: id, next: next, interval: interval, index: -1}
+		s.entries[id] = entry
+		heap.Push(&s.queue, entry)
+	}
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// remove unschedules an endpoint; a running check finishes but is not re-queued
+func (s *scheduler) remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, ok := s.entries[id]; ok {
+		s.removeLocked(entry)
+	}
+}
+
+// rename moves an endpoint's entry to a new ID, keeping a check in flight
+func (s *scheduler) rename(oldID, newID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[oldID]
+	if !ok {
+		return
+	}
+	if other, ok := s.entries[newID]; ok {
+		s.removeLocked(other)
+	}
+	delete(s.entries, oldID)
+	entry.id = newID
+	s.entries[newID] = entry
+}
+
+// scheduled returns the current ID of an entry and whether its endpoint is
+// still scheduled
+func (s *scheduler) scheduled(entry *scheduledCheck) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return entry.id, !entry.removed
+}
+
+// hold waits for a running check of an endpoint to finish and keeps the next
+// one from starting until the entry is released. It returns nil if the
+// endpoint is not scheduled.
+func (s *scheduler) hold(id string) *scheduledCheck {
+	s.mutex.Lock()
+	entry := s.entries[id]
+	s.mutex.Unlock()
+
+	if entry != nil {
+		entry.check.Lock()
+	}
+	return entry
+}
+
+// release lets the checks of an entry returned by hold run again
+func (s *scheduler) release(entry *scheduledCheck) {
+	if entry != nil {
+		entry.check.Unlock()
+	}
+}
+
+// removeLocked drops an entry from the queue and the index; callers hold the mutex
+func (s *scheduler) removeLocked(entry *scheduledCheck) {
+	entry.removed = true
+	if entry.index >= 0 {
+		heap.Remove(&s.queue, entry.index)
+	}
+	delete(s.entries, entry.id)
+}
+
+// trigger moves an endpoint's next check to now. It returns false if the
+// endpoint is unknown; a check already in flight is not duplicated.
+func (s *scheduler) trigger(id string) bool {
+	s.mutex.Lock()
+	entry, ok := s.entries[id]
+	if ok && !entry.inFlight {
+		entry.next = time.Now()
+		heap.Fix(&s.queue, entry.index)
+	}
+	s.mutex.Unlock()
+
+	if ok {
+		s.notify()
+	}
+	return ok
+}
+
+// done re-queues an endpoint after its check finished
+func (s *scheduler) done(entry *scheduledCheck) {
+	s.mutex.Lock()
+	entry.inFlight = false
+	if !entry.removed {
+		now := time.Now()
+		entry.next = entry.next.Add(entry.interval)
+		if entry.next.Before(now) {
+			// The check overran its interval; run again as soon as possible
+			// instead of bursting through the missed slots
+			entry.next = now
+		}
+		heap.Push(&s.queue, entry)
+	}
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// notify wakes the run loop so it re-evaluates the head of the queue
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run dispatches due checks until the context is cancelled. dispatch is called
+// for every due entry, must not block, and must call done once the check completes.
+func (s *scheduler) run(ctx context.Context, dispatch func(entry *scheduledCheck)) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mutex.Lock()
+		var due []*scheduledCheck
+		var wait time.Duration = -1
+		now := time.Now()
+		for len(s.queue) > 0 {
+			head := s.queue[0]
+			if head.next.After(now) {
+				wait = head.next.Sub(now)
+				break
+			}
+			heap.Pop(&s.queue)
+			head.inFlight = true
+			due = append(due, head)
+		}
+		s.mutex.Unlock()
+
+		for _, entry := range due {
+			dispatch(entry)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		var timerC <-chan time.Time
+		if wait >= 0 {
+			timer.Reset(wait)
+			timerC = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timerC:
+		case <-s.wake:
+		}
+	}
+}
+
+// clampInterval enforces the minimum check interval
+func clampInterval(interval time.Duration) time.Duration {
+	if interval < minCheckInterval {
+		return minCheckInterval
+	}
+	return interval
+}
@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// defaultRetryBackoff is the first retry delay when the policy sets none
+const defaultRetryBackoff = time.Second
+
+// retryBackoff returns the delay before the first retry
+func retryBackoff(policy *models.RetryPolicy) time.Duration {
+	if policy == nil || policy.Backoff <= 0 {
+		return defaultRetryBackoff
+	}
+	return time.Duration(policy.Backoff * float64(time.Second))
+}
+
+// nextBackoff doubles the delay, capped by the policy's max_backoff
+func nextBackoff(current time.Duration, policy *models.RetryPolicy) time.Duration {
+	next := current * 2
+	if policy != nil && policy.MaxBackoff > 0 {
+		if limit := time.Duration(policy.MaxBackoff * float64(time.Second)); next > limit {
+			next = limit
+		}
+	}
+	return next
+}
+
+// applyThresholds updates the consecutive counters from the latest raw result
+// and only changes the visible status once the relevant threshold is met.
+// The first result after an endpoint is added is applied immediately.
+func applyThresholds(endpoint *models.Endpoint) {
+	failureThreshold := endpoint.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+	successThreshold := endpoint.SuccessThreshold
+	if successThreshold <= 0 {
+		successThreshold = 1
+	}
+
+	if endpoint.LastResult == "down" {
+		endpoint.ConsecutiveFailures++
+		endpoint.ConsecutiveSuccesses = 0
+	} else {
+		endpoint.ConsecutiveSuccesses++
+		endpoint.ConsecutiveFailures = 0
+	}
+
+	switch {
+	case endpoint.Status == "checking" || endpoint.Status == "":
+		endpoint.Status = endpoint.LastResult
+	case endpoint.LastResult == "down":
+		if endpoint.ConsecutiveFailures >= failureThreshold {
+			endpoint.Status = "down"
+		}
+	case endpoint.Status == "down":
+		if endpoint.ConsecutiveSuccesses >= successThreshold {
+			endpoint.Status = endpoint.LastResult
+		}
+	default:
+		// Moving between up and degraded is not debounced
+		endpoint.Status = endpoint.LastResult
+	}
+}
@@ -0,0 +1,158 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/storage"
+)
+
+// pruneInterval is how often expired results are dropped from the store
+const pruneInterval = time.Hour
+
+// SetStore enables persistence of endpoints, check results and incidents.
+// Results and resolved incidents older than retention are pruned; zero keeps
+// them forever.
+func (m *Monitor) SetStore(store storage.Store, retention time.Duration) {
+	m.store = store
+	m.retention = retention
+}
+
+// Restore merges the configured endpoints with the state in the store and
+// returns the endpoints to monitor. Configured endpoints are matched with
+// stored ones by ID, or by name or URL when their ID changed, and keep the
+// history and open incident they had before the restart, taking over an
+// endpoint added through the API with the same ID. Other endpoints added
+// through the API are restored as stored, and stored config endpoints that
+// are no longer configured are deleted.
+func (m *Monitor) Restore(configured []*models.Endpoint) ([]*models.Endpoint, error) {
+	if m.store == nil {
+		return configured, nil
+	}
+
+	stored, err := m.store.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(configured))
+	for _, endpoint := range configured {
+		ids[endpoint.ID] = true
+	}
+	previous := make(map[string]*models.Endpoint, len(stored))
+	named := make(map[string]*models.Endpoint)
+	located := make(map[string]*models.Endpoint)
+	for _, endpoint := range stored {
+		previous[endpoint.ID] = endpoint
+		if endpoint.Source == models.SourceConfig && !ids[endpoint.ID] {
+			named[endpoint.Name] = endpoint
+			located[endpoint.URL] = endpoint
+		}
+	}
+
+	var endpoints []*models.Endpoint
+	for _, endpoint := range configured {
+		// Stored endpoints whose ID is no longer configured, because it was
+		// assigned by an earlier version or derived from a name or URL that
+		// changed since, are matched by name, or else by URL, and moved to
+		// their new ID
+		old, ok := named[endpoint.Name]
+		if !ok {
+			old, ok = located[endpoint.URL]
+		}
+		if ok && previous[endpoint.ID] == nil && previous[old.ID] == old {
+			if err := m.store.RenameEndpoint(old.ID, endpoint.ID); err != nil {
+				return nil, err
+			}
+			log.Printf("Moved stored endpoint %s from ID %s to %s", endpoint.Name, old.ID, endpoint.ID)
+			delete(previous, old.ID)
+		}
+		delete(previous, endpoint.ID)
+		endpoints = append(endpoints, endpoint)
+	}
+
+	// Stored endpoints no longer configured are dropped, while those added
+	// through the API are restored
+	for _, endpoint := range previous {
+		if endpoint.Source != models.SourceConfig {
+			endpoints = append(endpoints, endpoint)
+			continue
+		}
+		if err := m.store.DeleteEndpoint(endpoint.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, endpoint := range endpoints {
+		if endpoint.ID == "" {
+			continue
+		}
+		results, err := m.store.Results(endpoint.ID, time.Time{}, time.Time{}, m.historySize)
+		if err != nil {
+			return nil, err
+		}
+		buffer := newHistoryBuffer(m.historySize)
+		for _, result := range results {
+			buffer.add(result)
+		}
+		m.historyMutex.Lock()
+		m.history[endpoint.ID] = buffer
+		m.historyMutex.Unlock()
+	}
+
+	if err := m.restoreIncidents(endpoints); err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// persistEndpoint saves an endpoint definition if a store is configured
+func (m *Monitor) persistEndpoint(endpoint *models.Endpoint) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.SaveEndpoint(endpoint.Redacted()); err != nil {
+		log.Printf("Error saving endpoint %s: %v", endpoint.ID, err)
+	}
+}
+
+// persistResult saves a check result if a store is configured
+func (m *Monitor) persistResult(id string, result models.CheckResult) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.AppendResult(id, result); err != nil {
+		log.Printf("Error saving result for endpoint %s: %v", id, err)
+	}
+}
+
+// forgetEndpoint deletes a removed endpoint from the store
+func (m *Monitor) forgetEndpoint(id string) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.DeleteEndpoint(id); err != nil {
+		log.Printf("Error deleting endpoint %s: %v", id, err)
+	}
+}
+
+// pruneStore drops expired results and incidents until the context is cancelled
+func (m *Monitor) pruneStore(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.store.Prune(time.Now().Add(-m.retention)); err != nil {
+			log.Printf("Error pruning storage: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+	"health-caretaker/internal/storage"
+)
+
+func TestRestoreMovesChangedIDs(t *testing.T) {
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	defer store.Close()
+
+	orders := configEndpoint("Orders API", "https://orders.internal/healthz")
+	gone := configEndpoint("Legacy", "https://legacy.internal/healthz")
+	for _, endpoint := range []*models.Endpoint{orders, gone} {
+		if err := store.SaveEndpoint(endpoint); err != nil {
+			t.Fatalf("SaveEndpoint: %v", err)
+		}
+		if err := store.AppendResult(endpoint.ID, models.CheckResult{Timestamp: time.Now(), Status: "up"}); err != nil {
+			t.Fatalf("AppendResult: %v", err)
+		}
+	}
+
+	m := NewMonitor()
+	m.SetStore(store, 0)
+	moved := configEndpoint("Orders API", "https://orders.internal/readyz")
+	endpoints, err := m.Restore([]*models.Endpoint{moved})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(endpoints) != 1 || endpoints[0].ID != moved.ID {
+		t.Fatalf("Restore returned %d endpoints, want only %s", len(endpoints), moved.ID)
+	}
+	if history := m.GetHistory(moved.ID, time.Time{}, time.Time{}, 0); len(history) != 1 {
+		t.Errorf("%d results under the new ID, want 1", len(history))
+	}
+
+	stored, err := store.Endpoints()
+	if err != nil {
+		t.Fatalf("Endpoints: %v", err)
+	}
+	if len(stored) != 1 || stored[0].ID != moved.ID {
+		t.Errorf("stored endpoints = %+v, want only %s", stored, moved.ID)
+	}
+}
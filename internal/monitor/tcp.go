@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// maxTCPResponseSize caps how much of a TCP response is read while matching
+const maxTCPResponseSize = 64 * 1024
+
+// checkTCP connects to a tcp://host:port endpoint, optionally sends a payload
+// and matches the response against the expected pattern
+func (m *Monitor) checkTCP(endpoint *models.Endpoint) {
+	start := time.Now()
+	timeout := time.Duration(endpoint.Timeout) * time.Second
+
+	endpoint.StatusCode = 0
+	endpoint.ConnectTime = 0
+
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = fmt.Sprintf("Invalid URL: %v", err)
+		endpoint.LastCheck = time.Now()
+		return
+	}
+
+	conn, err := net.DialTimeout("tcp", u.Host, timeout)
+	connectTime := time.Since(start)
+
+	endpoint.LastCheck = time.Now()
+	endpoint.ConnectTime = connectTime.Milliseconds()
+
+	if err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		endpoint.ResponseTime = connectTime.Milliseconds()
+		return
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(start.Add(timeout)); err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+		endpoint.ResponseTime = time.Since(start).Milliseconds()
+		return
+	}
+
+	if err := exchangeTCP(conn, endpoint.TCP); err != nil {
+		endpoint.LastResult = "down"
+		endpoint.Error = err.Error()
+	} else {
+		endpoint.LastResult = "up"
+		endpoint.Error = ""
+	}
+
+	endpoint.LastCheck = time.Now()
+	endpoint.ResponseTime = time.Since(start).Milliseconds()
+}
+
+// exchangeTCP writes the configured payload and waits for the expected response
+func exchangeTCP(conn net.Conn, probe *models.TCPProbe) error {
+	if probe == nil {
+		return nil
+	}
+
+	if probe.Send != "" {
+		if _, err := io.WriteString(conn, probe.Send); err != nil {
+			return fmt.Errorf("failed to send payload: %v", err)
+		}
+	}
+
+	if probe.Expect == "" {
+		return nil
+	}
+
+	expect, err := regexp.Compile(probe.Expect)
+	if err != nil {
+		return fmt.Errorf("invalid expect pattern: %v", err)
+	}
+
+	var response bytes.Buffer
+	buf := make([]byte, 4096)
+	for response.Len() < maxTCPResponseSize {
+		n, err := conn.Read(buf)
+		response.Write(buf[:n])
+		if expect.Match(response.Bytes()) {
+			return nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("response did not match %q: %v", probe.Expect, err)
+		}
+	}
+
+	return fmt.Errorf("response did not match %q", probe.Expect)
+}
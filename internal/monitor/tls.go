@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// buildTLSConfig creates the client TLS configuration for an endpoint's policy.
+// Without a policy, certificates are not verified to allow self-signed endpoints.
+func buildTLSConfig(policy *models.TLSPolicy) (*tls.Config, error) {
+	if policy == nil {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	roots, err := loadCAFile(policy.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: !policy.Verify,
+		RootCAs:            roots,
+		ServerName:         policy.ServerName,
+	}, nil
+}
+
+// loadCAFile reads a PEM bundle into a certificate pool; an empty path means system roots
+func loadCAFile(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// inspectTLS records the peer certificate and verifies the presented chain,
+// independently of whether the policy enforced verification during the handshake
+func inspectTLS(endpoint *models.Endpoint, state *tls.ConnectionState) *models.TLSCertInfo {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	info := &models.TLSCertInfo{
+		Subject:        leaf.Subject.String(),
+		Issuer:         leaf.Issuer.String(),
+		DNSNames:       leaf.DNSNames,
+		NotAfter:       leaf.NotAfter,
+		EarliestExpiry: leaf.NotAfter,
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+		if cert.NotAfter.Before(info.EarliestExpiry) {
+			info.EarliestExpiry = cert.NotAfter
+		}
+	}
+
+	opts := x509.VerifyOptions{
+		DNSName:       verifyName(endpoint),
+		Intermediates: intermediates,
+	}
+	if endpoint.TLS != nil {
+		roots, err := loadCAFile(endpoint.TLS.CAFile)
+		if err != nil {
+			info.ChainError = err.Error()
+			return info
+		}
+		opts.Roots = roots
+	}
+
+	if _, err := leaf.Verify(opts); err != nil {
+		info.ChainError = err.Error()
+	} else {
+		info.ChainValid = true
+	}
+
+	return info
+}
+
+// verifyName returns the name the certificate is expected to be valid for
+func verifyName(endpoint *models.Endpoint) string {
+	if endpoint.TLS != nil && endpoint.TLS.ServerName != "" {
+		return endpoint.TLS.ServerName
+	}
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// applyCertExpiry flags an otherwise healthy endpoint whose certificate expires
+// within the policy threshold
+func applyCertExpiry(endpoint *models.Endpoint) {
+	policy := endpoint.TLS
+	if policy == nil || policy.ExpiryThresholdDays <= 0 || endpoint.TLSCert == nil || endpoint.LastResult != "up" {
+		return
+	}
+
+	remaining := time.Until(endpoint.TLSCert.EarliestExpiry)
+	if remaining.Hours()/24 >= float64(policy.ExpiryThresholdDays) {
+		return
+	}
+
+	endpoint.LastResult = "degraded"
+	if policy.ExpiryStatus == "down" {
+		endpoint.LastResult = "down"
+	}
+
+	if remaining <= 0 {
+		endpoint.Error = fmt.Sprintf("TLS certificate expired on %s", endpoint.TLSCert.EarliestExpiry.Format(time.RFC3339))
+	} else {
+		endpoint.Error = fmt.Sprintf("TLS certificate expires in %d days", int(remaining.Hours()/24))
+	}
+}
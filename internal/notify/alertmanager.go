@@ -0,0 +1,200 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+const (
+	// DefaultAlertName is the alertname label of endpoint alerts
+	DefaultAlertName = "EndpointDown"
+
+	// defaultResendInterval is how often firing alerts are re-sent
+	defaultResendInterval = time.Minute
+
+	// alertValidity is how many resend intervals a firing alert stays valid
+	// in Alertmanager without being re-sent
+	alertValidity = 4
+)
+
+// AlertmanagerConfig holds the options for an Alertmanager notifier
+type AlertmanagerConfig struct {
+	AlertName      string            `json:"alert_name,omitempty"`      // alertname label (default EndpointDown)
+	Labels         map[string]string `json:"labels,omitempty"`          // Static labels added to every alert
+	ResendInterval float64           `json:"resend_interval,omitempty"` // Seconds between re-sends of firing alerts (default 60)
+	GeneratorURL   string            `json:"generator_url,omitempty"`   // Link back to health-caretaker, e.g. the dashboard URL
+	Headers        map[string]string `json:"headers,omitempty"`         // Extra request headers, e.g. for an authenticating proxy
+}
+
+// alertmanagerAlert is an alert in the Alertmanager API v2 format
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// alertmanager pushes alerts to Alertmanager and keeps firing alerts alive.
+// Firing alerts are only kept in memory: after a restart, the alerts of
+// endpoints that are still down are no longer re-sent and expire once their
+// endsAt passes, and their recoveries resolve them with the label set built
+// from the recovery.
+type alertmanager struct {
+	name    string
+	url     string
+	options AlertmanagerConfig
+	resend  time.Duration
+	client  *http.Client
+	mutex   sync.Mutex
+	active  map[string]alertmanagerAlert // Firing alerts by endpoint ID
+}
+
+// newAlertmanager creates an Alertmanager notifier for the instance at url
+func newAlertmanager(c Config) (*alertmanager, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("alertmanager notifier requires a url")
+	}
+	baseURL, err := validateBaseURL(c, "")
+	if err != nil {
+		return nil, err
+	}
+
+	a := &alertmanager{
+		name:   c.Name,
+		url:    baseURL + "/api/v2/alerts",
+		resend: defaultResendInterval,
+		client: &http.Client{},
+		active: make(map[string]alertmanagerAlert),
+	}
+	if c.Alertmanager != nil {
+		a.options = *c.Alertmanager
+	}
+	if a.options.ResendInterval < 0 {
+		return nil, fmt.Errorf("resend_interval must not be negative")
+	}
+	if a.options.ResendInterval > 0 {
+		a.resend = time.Duration(a.options.ResendInterval * float64(time.Second))
+	}
+	if a.options.AlertName == "" {
+		a.options.AlertName = DefaultAlertName
+	}
+	return a, nil
+}
+
+// Name returns the configured notifier name
+func (a *alertmanager) Name() string {
+	return a.name
+}
+
+// alert builds the Alertmanager alert for a transition
+func (a *alertmanager) alert(transition models.Transition) alertmanagerAlert {
+	labels := make(map[string]string, len(transition.Labels)+len(a.options.Labels)+3)
+	for name, value := range transition.Labels {
+		labels[models.SanitizeLabelName(name)] = value
+	}
+	for name, value := range a.options.Labels {
+		labels[name] = value
+	}
+	labels["alertname"] = a.options.AlertName
+	labels["endpoint"] = transition.EndpointName
+	labels["endpoint_id"] = transition.EndpointID
+
+	annotations := map[string]string{
+		"summary": fmt.Sprintf("%s is down", transition.EndpointName),
+		"url":     transition.URL,
+	}
+	if transition.Error != "" {
+		annotations["description"] = transition.Error
+	}
+	if transition.StatusCode != 0 {
+		annotations["status_code"] = strconv.Itoa(transition.StatusCode)
+	}
+
+	return alertmanagerAlert{
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     transition.DownSince,
+		GeneratorURL: a.options.GeneratorURL,
+	}
+}
+
+// Notify fires an alert when the endpoint goes down and resolves it on recovery
+func (a *alertmanager) Notify(ctx context.Context, transition models.Transition) error {
+	alert := a.alert(transition)
+
+	a.mutex.Lock()
+	if transition.IsRecovery() {
+		if active, ok := a.active[transition.EndpointID]; ok {
+			// Resolve with the exact label set that fired
+			alert.Labels = active.Labels
+		}
+		delete(a.active, transition.EndpointID)
+		alert.EndsAt = transition.Time
+	} else {
+		a.active[transition.EndpointID] = alert
+		alert.EndsAt = time.Now().Add(alertValidity * a.resend)
+	}
+	a.mutex.Unlock()
+
+	return a.post(ctx, []alertmanagerAlert{alert})
+}
+
+// Run re-sends firing alerts every resend interval so Alertmanager does not
+// expire them while the endpoints are still down
+func (a *alertmanager) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.resend)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		a.mutex.Lock()
+		alerts := make([]alertmanagerAlert, 0, len(a.active))
+		endsAt := time.Now().Add(alertValidity * a.resend)
+		for _, alert := range a.active {
+			alert.EndsAt = endsAt
+			alerts = append(alerts, alert)
+		}
+		a.mutex.Unlock()
+
+		if len(alerts) == 0 {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, a.resend)
+		if err := a.post(sendCtx, alerts); err != nil {
+			log.Printf("Notifier %s failed to re-send %d alerts: %v", a.name, len(alerts), err)
+		}
+		cancel()
+	}
+}
+
+// post sends alerts to the Alertmanager API
+func (a *alertmanager) post(ctx context.Context, alerts []alertmanagerAlert) error {
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range a.options.Headers {
+		req.Header.Set(name, value)
+	}
+	return send(a.client, req)
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAlertmanagerAlerts(t *testing.T) {
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name: "alertmanager",
+		Type: "alertmanager",
+		URL:  r.URL,
+		Alertmanager: &AlertmanagerConfig{
+			Labels:         map[string]string{"source": "health-caretaker"},
+			ResendInterval: 60,
+			GeneratorURL:   "https://health.example.com/",
+			Headers:        map[string]string{"X-Scope-OrgID": "shop"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	down := downTransition()
+	down.Labels["app.kubernetes.io/part-of"] = "checkout"
+	down.Labels["2nd-team"] = "payments"
+	sent := time.Now()
+	if err := notifier.Notify(context.Background(), down); err != nil {
+		t.Fatalf("fire: %v", err)
+	}
+	// A label change before the recovery does not keep the alert from resolving
+	recovery := recoveryTransition()
+	recovery.Labels = map[string]string{"team": "checkout"}
+	if err := notifier.Notify(context.Background(), recovery); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	requests := r.received()
+	if len(requests) != 2 {
+		t.Fatalf("received %d requests, want 2", len(requests))
+	}
+	var fired, resolved []alertmanagerAlert
+	for i, alerts := range []*[]alertmanagerAlert{&fired, &resolved} {
+		req := requests[i]
+		if req.Method != "POST" || req.URI != "/api/v2/alerts" {
+			t.Errorf("request %d = %s %s, want POST /api/v2/alerts", i, req.Method, req.URI)
+		}
+		if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("X-Scope-OrgID") != "shop" {
+			t.Errorf("request %d headers = %v", i, req.Header)
+		}
+		if err := json.Unmarshal(req.Body, alerts); err != nil {
+			t.Fatalf("invalid alerts %s: %v", req.Body, err)
+		}
+		if len(*alerts) != 1 {
+			t.Fatalf("request %d has %d alerts, want 1", i, len(*alerts))
+		}
+	}
+
+	alert := fired[0]
+	wantLabels := map[string]string{
+		"alertname":                 "EndpointDown",
+		"endpoint":                  "Orders API",
+		"endpoint_id":               "orders",
+		"source":                    "health-caretaker",
+		"team":                      "shop",
+		"criticality":               "high",
+		"app_kubernetes_io_part_of": "checkout",
+		"_nd_team":                  "payments",
+	}
+	if len(alert.Labels) != len(wantLabels) {
+		t.Errorf("labels = %v, want %v", alert.Labels, wantLabels)
+	}
+	for name, value := range wantLabels {
+		if alert.Labels[name] != value {
+			t.Errorf("label %s = %q, want %q", name, alert.Labels[name], value)
+		}
+	}
+	if alert.Annotations["summary"] != "Orders API is down" || alert.Annotations["description"] != "status code 503" || alert.Annotations["status_code"] != "503" {
+		t.Errorf("annotations = %v", alert.Annotations)
+	}
+	if !alert.StartsAt.Equal(down.DownSince) {
+		t.Errorf("startsAt = %v, want the start of the outage %v", alert.StartsAt, down.DownSince)
+	}
+	// Firing alerts stay valid for four resend intervals
+	if validUntil := sent.Add(4 * time.Minute); alert.EndsAt.Before(validUntil) || alert.EndsAt.After(validUntil.Add(time.Minute)) {
+		t.Errorf("endsAt = %v, want about %v", alert.EndsAt, validUntil)
+	}
+	if alert.GeneratorURL != "https://health.example.com/" {
+		t.Errorf("generatorURL = %q", alert.GeneratorURL)
+	}
+
+	resolve := resolved[0]
+	if !resolve.EndsAt.Equal(recovery.Time) {
+		t.Errorf("resolve endsAt = %v, want the recovery time %v", resolve.EndsAt, recovery.Time)
+	}
+	if len(resolve.Labels) != len(alert.Labels) || resolve.Labels["team"] != "shop" {
+		t.Errorf("resolve labels = %v, want the labels that fired %v", resolve.Labels, alert.Labels)
+	}
+}
+
+func TestAlertmanagerResend(t *testing.T) {
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name:         "alertmanager",
+		Type:         "alertmanager",
+		URL:          r.URL,
+		Alertmanager: &AlertmanagerConfig{ResendInterval: 0.05},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifier.(Runner).Run(ctx)
+
+	if err := notifier.Notify(ctx, downTransition()); err != nil {
+		t.Fatalf("fire: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.received()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	requests := r.received()
+	if len(requests) < 3 {
+		t.Fatalf("received %d requests, want the alert and re-sends", len(requests))
+	}
+	var first, resent []alertmanagerAlert
+	if err := json.Unmarshal(requests[0].Body, &first); err != nil || len(first) != 1 {
+		t.Fatalf("fired %s (%v), want one alert", requests[0].Body, err)
+	}
+	if err := json.Unmarshal(requests[len(requests)-1].Body, &resent); err != nil || len(resent) != 1 {
+		t.Fatalf("re-sent %s (%v), want one alert", requests[len(requests)-1].Body, err)
+	}
+	if !resent[0].StartsAt.Equal(first[0].StartsAt) || !resent[0].EndsAt.After(first[0].EndsAt) {
+		t.Errorf("re-sent alert = %+v, want the same start with a later end than %+v", resent[0], first[0])
+	}
+
+	// Nothing is re-sent once the alert is resolved
+	if err := notifier.Notify(ctx, recoveryTransition()); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	// A re-send that was already under way may still arrive
+	time.Sleep(100 * time.Millisecond)
+	resolvedAt := len(r.received())
+	time.Sleep(200 * time.Millisecond)
+	if got := len(r.received()); got != resolvedAt {
+		t.Errorf("%d requests after the resolve, want none", got-resolvedAt)
+	}
+}
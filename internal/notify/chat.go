@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// defaultChatLabels are the endpoint labels shown in chat messages
+var defaultChatLabels = []string{"team", "criticality"}
+
+// ChatConfig holds the options for Slack, Teams and Mattermost notifiers
+type ChatConfig struct {
+	Channel   string   `json:"channel,omitempty"`    // Channel override, if the webhook allows it (Slack, Mattermost)
+	Username  string   `json:"username,omitempty"`   // Sender name override (Slack, Mattermost)
+	IconEmoji string   `json:"icon_emoji,omitempty"` // Sender icon, e.g. ":rotating_light:" (Slack, Mattermost)
+	IconURL   string   `json:"icon_url,omitempty"`   // Sender icon image (Slack, Mattermost)
+	Labels    []string `json:"labels,omitempty"`     // Endpoint labels to show (default team and criticality)
+}
+
+// chatField is a single name/value line of a chat message
+type chatField struct {
+	Name  string
+	Value string
+	Short bool
+}
+
+// chatMessage is the destination-independent content of a chat notification
+type chatMessage struct {
+	Title  string
+	Text   string
+	URL    string
+	Color  string // Hex color without '#'
+	Fields []chatField
+	Time   time.Time
+}
+
+// newChatMessage summarises a transition for chat destinations
+func newChatMessage(transition models.Transition, labels []string) chatMessage {
+	msg := chatMessage{URL: transition.URL, Time: transition.Time}
+	if transition.IsRecovery() {
+		msg.Title = fmt.Sprintf("RECOVERED: %s is %s", transition.EndpointName, transition.To)
+		msg.Text = fmt.Sprintf("Back %s after %s of downtime.", transition.To, formatDuration(transition.Duration()))
+		msg.Color = "2EB67D"
+	} else if transition.Reminder {
+		msg.Title = fmt.Sprintf("STILL DOWN: %s", transition.EndpointName)
+		msg.Text = fmt.Sprintf("%s has been down for %s.", transition.EndpointName, formatDuration(transition.Duration()))
+		msg.Color = "E01E5A"
+	} else {
+		msg.Title = fmt.Sprintf("DOWN: %s", transition.EndpointName)
+		msg.Text = fmt.Sprintf("%s went down (was %s).", transition.EndpointName, transition.From)
+		msg.Color = "E01E5A"
+	}
+
+	msg.Fields = append(msg.Fields, chatField{Name: "URL", Value: transition.URL})
+	if transition.StatusCode != 0 {
+		msg.Fields = append(msg.Fields, chatField{Name: "Status Code", Value: strconv.Itoa(transition.StatusCode), Short: true})
+	}
+	if transition.IsRecovery() {
+		msg.Fields = append(msg.Fields, chatField{Name: "Outage Duration", Value: formatDuration(transition.Duration()), Short: true})
+	}
+
+	if len(labels) == 0 {
+		labels = defaultChatLabels
+	}
+	for _, label := range labels {
+		if value, ok := transition.Labels[label]; ok {
+			msg.Fields = append(msg.Fields, chatField{Name: label, Value: value, Short: true})
+		}
+	}
+
+	if transition.Error != "" {
+		msg.Fields = append(msg.Fields, chatField{Name: "Error", Value: transition.Error})
+	}
+	return msg
+}
+
+// formatDuration rounds a duration for display
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "less than a second"
+	}
+	return d.Round(time.Second).String()
+}
+
+// chatNotifier posts formatted messages to a chat incoming webhook
+type chatNotifier struct {
+	name    string
+	url     string
+	options ChatConfig
+	format  func(msg chatMessage, options ChatConfig) interface{}
+	client  *http.Client
+}
+
+// newChat creates a Slack, Teams or Mattermost notifier
+func newChat(c Config, format func(chatMessage, ChatConfig) interface{}) (*chatNotifier, error) {
+	if _, err := url.ParseRequestURI(c.URL); err != nil || c.URL == "" {
+		return nil, fmt.Errorf("%s notifier requires a valid webhook url", c.Type)
+	}
+
+	n := &chatNotifier{name: c.Name, url: c.URL, format: format, client: &http.Client{}}
+	if c.Chat != nil {
+		n.options = *c.Chat
+	}
+	return n, nil
+}
+
+// Name returns the configured notifier name
+func (n *chatNotifier) Name() string {
+	return n.name
+}
+
+// Notify posts the formatted message to the webhook
+func (n *chatNotifier) Notify(ctx context.Context, transition models.Transition) error {
+	payload := n.format(newChatMessage(transition, n.options.Labels), n.options)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(n.client, req)
+}
+
+// slackAttachment is a message attachment understood by Slack and Mattermost
+type slackAttachment struct {
+	Fallback  string       `json:"fallback"`
+	Color     string       `json:"color"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link,omitempty"`
+	Text      string       `json:"text,omitempty"`
+	Fields    []slackField `json:"fields,omitempty"`
+	Footer    string       `json:"footer,omitempty"`
+	Timestamp int64        `json:"ts,omitempty"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// slackPayload is an incoming-webhook message for Slack and Mattermost
+type slackPayload struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// formatSlack renders a message with a colored attachment. Mattermost accepts
+// the same Slack-compatible format.
+func formatSlack(msg chatMessage, options ChatConfig) interface{} {
+	attachment := slackAttachment{
+		Fallback:  msg.Title + ": " + msg.Text,
+		Color:     "#" + msg.Color,
+		Title:     msg.Title,
+		TitleLink: msg.URL,
+		Text:      msg.Text,
+		Footer:    "health-caretaker",
+		Timestamp: msg.Time.Unix(),
+	}
+	for _, field := range msg.Fields {
+		value := field.Value
+		if field.Name == "Error" {
+			value = "```" + value + "```"
+		}
+		attachment.Fields = append(attachment.Fields, slackField{Title: field.Name, Value: value, Short: field.Short})
+	}
+
+	return slackPayload{
+		Channel:     options.Channel,
+		Username:    options.Username,
+		IconEmoji:   options.IconEmoji,
+		IconURL:     options.IconURL,
+		Text:        msg.Title,
+		Attachments: []slackAttachment{attachment},
+	}
+}
+
+// teamsCard is an Office 365 connector MessageCard for Microsoft Teams
+type teamsCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title"`
+	Sections   []teamsSection `json:"sections"`
+	Actions    []teamsAction  `json:"potentialAction,omitempty"`
+}
+
+type teamsSection struct {
+	ActivityTitle string      `json:"activityTitle"`
+	Facts         []teamsFact `json:"facts"`
+	Markdown      bool        `json:"markdown"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	Targets []teamsTarget `json:"targets"`
+}
+
+type teamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+// formatTeams renders a message as a Teams MessageCard
+func formatTeams(msg chatMessage, _ ChatConfig) interface{} {
+	section := teamsSection{ActivityTitle: msg.Text, Markdown: true}
+	for _, field := range msg.Fields {
+		section.Facts = append(section.Facts, teamsFact{Name: field.Name, Value: field.Value})
+	}
+
+	card := teamsCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: msg.Color,
+		Summary:    msg.Title,
+		Title:      msg.Title,
+		Sections:   []teamsSection{section},
+	}
+	if u, err := url.Parse(msg.URL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		card.Actions = []teamsAction{{
+			Type:    "OpenUri",
+			Name:    "Open endpoint",
+			Targets: []teamsTarget{{OS: "default", URI: msg.URL}},
+		}}
+	}
+	return card
+}
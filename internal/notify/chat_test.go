@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSlackPayload(t *testing.T) {
+	for _, kind := range []string{"slack", "mattermost"} {
+		t.Run(kind, func(t *testing.T) {
+			r := newReceiver(t)
+			notifier, err := New(Config{
+				Name: kind,
+				Type: kind,
+				URL:  r.URL,
+				Chat: &ChatConfig{Channel: "#alerts", Username: "caretaker", IconEmoji: ":rotating_light:"},
+			})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if err := notifier.Notify(context.Background(), downTransition()); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+
+			var got slackPayload
+			req := r.decode(t, &got)
+			if req.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q", req.Header.Get("Content-Type"))
+			}
+			if got.Channel != "#alerts" || got.Username != "caretaker" || got.IconEmoji != ":rotating_light:" {
+				t.Errorf("overrides = %q %q %q", got.Channel, got.Username, got.IconEmoji)
+			}
+			if got.Text != "DOWN: Orders API" || len(got.Attachments) != 1 {
+				t.Fatalf("payload = %+v", got)
+			}
+			attachment := got.Attachments[0]
+			if attachment.Color != "#E01E5A" || attachment.TitleLink != "https://orders.internal/healthz" || attachment.Timestamp == 0 {
+				t.Errorf("attachment = %+v", attachment)
+			}
+			fields := make(map[string]string)
+			for _, field := range attachment.Fields {
+				fields[field.Title] = field.Value
+			}
+			want := map[string]string{
+				"URL":         "https://orders.internal/healthz",
+				"Status Code": "503",
+				"team":        "shop",
+				"criticality": "high",
+				"Error":       "```status code 503```",
+			}
+			for name, value := range want {
+				if fields[name] != value {
+					t.Errorf("field %s = %q, want %q", name, fields[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestSlackRecoveryPayload(t *testing.T) {
+	r := newReceiver(t)
+	notifier, err := New(Config{Name: "slack", Type: "slack", URL: r.URL, Chat: &ChatConfig{Labels: []string{"team"}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := notifier.Notify(context.Background(), recoveryTransition()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var got slackPayload
+	r.decode(t, &got)
+	attachment := got.Attachments[0]
+	if got.Text != "RECOVERED: Orders API is up" || attachment.Color != "#2EB67D" {
+		t.Errorf("payload = %+v", got)
+	}
+	if !strings.Contains(attachment.Text, "1m30s") {
+		t.Errorf("text = %q, want the outage duration", attachment.Text)
+	}
+	for _, field := range attachment.Fields {
+		if field.Title == "criticality" || field.Title == "Error" {
+			t.Errorf("unexpected field %s", field.Title)
+		}
+	}
+}
+
+func TestTeamsPayload(t *testing.T) {
+	r := newReceiver(t)
+	notifier, err := New(Config{Name: "teams", Type: "teams", URL: r.URL})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := notifier.Notify(context.Background(), downTransition()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var got teamsCard
+	r.decode(t, &got)
+	if got.Type != "MessageCard" || got.Context != "https://schema.org/extensions" {
+		t.Errorf("card type = %q %q", got.Type, got.Context)
+	}
+	if got.Title != "DOWN: Orders API" || got.Summary != got.Title || got.ThemeColor != "E01E5A" {
+		t.Errorf("card = %+v", got)
+	}
+	if len(got.Sections) != 1 || got.Sections[0].ActivityTitle != "Orders API went down (was up)." {
+		t.Fatalf("sections = %+v", got.Sections)
+	}
+	facts := make(map[string]string)
+	for _, fact := range got.Sections[0].Facts {
+		facts[fact.Name] = fact.Value
+	}
+	if facts["Status Code"] != "503" || facts["Error"] != "status code 503" || facts["team"] != "shop" {
+		t.Errorf("facts = %v", facts)
+	}
+	if len(got.Actions) != 1 || got.Actions[0].Type != "OpenUri" || got.Actions[0].Targets[0].URI != "https://orders.internal/healthz" {
+		t.Errorf("actions = %+v", got.Actions)
+	}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// DeadLetter is a transition that could not be delivered
+type DeadLetter struct {
+	Time       time.Time         `json:"time"`
+	Notifier   string            `json:"notifier"`
+	Attempts   int               `json:"attempts"`
+	Error      string            `json:"error"`
+	Transition models.Transition `json:"transition"`
+}
+
+// DeadLetterLog appends undeliverable transitions to a JSON lines file.
+// Without a file they are only logged.
+type DeadLetterLog struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewDeadLetterLog opens the dead-letter file for appending; an empty path
+// logs dead letters without writing them to disk
+func NewDeadLetterLog(path string) (*DeadLetterLog, error) {
+	if path == "" {
+		return &DeadLetterLog{}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter log: %v", err)
+	}
+	return &DeadLetterLog{file: file}, nil
+}
+
+// Record logs an undeliverable transition
+func (l *DeadLetterLog) Record(notifier string, transition models.Transition, attempts int, err error) {
+	log.Printf("Notifier %s gave up on %s (%s -> %s) after %d attempts: %v",
+		notifier, transition.EndpointName, transition.From, transition.To, attempts, err)
+
+	if l == nil || l.file == nil {
+		return
+	}
+
+	data, marshalErr := json.Marshal(DeadLetter{
+		Time:       time.Now(),
+		Notifier:   notifier,
+		Attempts:   attempts,
+		Error:      err.Error(),
+		Transition: transition,
+	})
+	if marshalErr != nil {
+		log.Printf("Error marshaling dead letter: %v", marshalErr)
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, writeErr := l.file.Write(append(data, '\n')); writeErr != nil {
+		log.Printf("Error writing dead letter: %v", writeErr)
+	}
+}
+
+// Close closes the dead-letter file
+func (l *DeadLetterLog) Close() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
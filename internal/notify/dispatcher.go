@@ -0,0 +1,276 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+const (
+	// queueSize bounds the transitions waiting for a single notifier
+	queueSize = 256
+
+	// defaultRetries is the number of redeliveries when no retry policy is set
+	defaultRetries = 3
+
+	// defaultBackoff and defaultMaxBackoff bound the delay between redeliveries
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = time.Minute
+)
+
+// Dispatcher fans transitions out to notifiers. Every notifier has its own
+// queue and worker, so a slow destination does not delay the others and
+// deliveries to one destination keep their order.
+type Dispatcher struct {
+	workers    []*worker
+	deadLetter *DeadLetterLog
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// worker delivers queued transitions to a single notifier
+type worker struct {
+	notifier Notifier
+	config   Config
+	queue    chan []models.Transition // Transitions grouped by the caller
+}
+
+// NewDispatcher creates a dispatcher for the configured notifiers.
+// Undeliverable transitions are appended to the dead-letter log.
+func NewDispatcher(configs []Config, deadLetter *DeadLetterLog) (*Dispatcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{deadLetter: deadLetter, ctx: ctx, cancel: cancel}
+
+	for _, config := range configs {
+		notifier, err := New(config)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		d.Add(notifier, config)
+	}
+
+	return d, nil
+}
+
+// Add registers a notifier and starts its worker
+func (d *Dispatcher) Add(notifier Notifier, config Config) {
+	w := &worker{
+		notifier: notifier,
+		config:   config,
+		queue:    make(chan []models.Transition, queueSize),
+	}
+	d.workers = append(d.workers, w)
+
+	if runner, ok := notifier.(Runner); ok {
+		go runner.Run(d.ctx)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if batcher, ok := notifier.(BatchNotifier); ok && batcher.Digest() > 0 {
+			d.collect(w, batcher)
+			return
+		}
+		for group := range w.queue {
+			d.deliverGroup(w, group)
+		}
+	}()
+}
+
+// deliverGroup delivers grouped transitions together to batch notifiers and
+// one by one to all others
+func (d *Dispatcher) deliverGroup(w *worker, group []models.Transition) {
+	if _, ok := w.notifier.(BatchNotifier); ok {
+		d.deliverBatch(w, group)
+		return
+	}
+	for _, transition := range group {
+		d.deliver(w, []models.Transition{transition})
+	}
+}
+
+// deliverBatch delivers a batch in order, keeping acknowledgements out of
+// the runs of transitions that are delivered together. Acknowledgements are
+// delivered one by one.
+func (d *Dispatcher) deliverBatch(w *worker, batch []models.Transition) {
+	for len(batch) > 0 {
+		n := 1
+		for n < len(batch) && batch[n].Acknowledged == batch[0].Acknowledged {
+			n++
+		}
+		if batch[0].Acknowledged {
+			for _, transition := range batch[:n] {
+				d.deliver(w, []models.Transition{transition})
+			}
+		} else {
+			d.deliver(w, batch[:n])
+		}
+		batch = batch[n:]
+	}
+}
+
+// collect gathers transitions for a digest notifier and delivers them in one
+// batch once the digest period after the first of them has passed
+func (d *Dispatcher) collect(w *worker, batcher BatchNotifier) {
+	var batch []models.Transition
+	var flush <-chan time.Time
+
+	for {
+		select {
+		case group, ok := <-w.queue:
+			if !ok {
+				if len(batch) > 0 {
+					d.deliverBatch(w, batch)
+				}
+				return
+			}
+			if len(batch) == 0 {
+				flush = time.After(batcher.Digest())
+			}
+			batch = append(batch, group...)
+		case <-flush:
+			d.deliverBatch(w, batch)
+			batch = nil
+			flush = nil
+		}
+	}
+}
+
+// Dispatch queues a transition for every notifier whose match set accepts
+// the endpoint's labels; it never blocks
+func (d *Dispatcher) Dispatch(transition models.Transition) {
+	for _, w := range d.workers {
+		d.enqueue(w, []models.Transition{transition})
+	}
+}
+
+// DispatchTo queues a group of transitions for the named notifier, keeping
+// those its match set accepts; it never blocks
+func (d *Dispatcher) DispatchTo(receiver string, transitions []models.Transition) {
+	for _, w := range d.workers {
+		if w.notifier.Name() == receiver {
+			d.enqueue(w, transitions)
+			return
+		}
+	}
+	log.Printf("Notification for unknown receiver %s dropped", receiver)
+}
+
+// Receivers returns the names of all notifiers
+func (d *Dispatcher) Receivers() []string {
+	names := make([]string, len(d.workers))
+	for i, w := range d.workers {
+		names[i] = w.notifier.Name()
+	}
+	return names
+}
+
+// enqueue queues the matching transitions for a worker, dead-lettering them
+// if its queue is full. Acknowledgements are only queued for Acknowledgers.
+func (d *Dispatcher) enqueue(w *worker, transitions []models.Transition) {
+	_, acknowledges := w.notifier.(Acknowledger)
+	var group []models.Transition
+	for _, transition := range transitions {
+		if w.config.Matches(transition) && (acknowledges || !transition.Acknowledged) {
+			group = append(group, transition)
+		}
+	}
+	if len(group) == 0 {
+		return
+	}
+
+	select {
+	case w.queue <- group:
+	default:
+		d.deadLetterAll(w, group, 0, errors.New("notification queue is full"))
+	}
+}
+
+// deliver sends transitions with retries, dead-lettering them if every
+// attempt fails. An acknowledgement is always delivered on its own.
+func (d *Dispatcher) deliver(w *worker, transitions []models.Transition) {
+	retries := defaultRetries
+	backoff := defaultBackoff
+	maxBackoff := defaultMaxBackoff
+	if policy := w.config.Retry; policy != nil {
+		retries = policy.Attempts
+		if policy.Backoff > 0 {
+			backoff = time.Duration(policy.Backoff * float64(time.Second))
+		}
+		if policy.MaxBackoff > 0 {
+			maxBackoff = time.Duration(policy.MaxBackoff * float64(time.Second))
+		}
+	}
+
+	var err error
+	attempt := 0
+	for {
+		attempt++
+		ctx, cancel := context.WithTimeout(d.ctx, w.config.timeout())
+		if acknowledger, ok := w.notifier.(Acknowledger); ok && transitions[0].Acknowledged {
+			err = acknowledger.Acknowledge(ctx, transitions[0])
+		} else if batcher, ok := w.notifier.(BatchNotifier); ok && len(transitions) > 1 {
+			err = batcher.NotifyBatch(ctx, transitions)
+		} else {
+			err = w.notifier.Notify(ctx, transitions[0])
+		}
+		cancel()
+		if err == nil {
+			return
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt > retries {
+			break
+		}
+
+		log.Printf("Notifier %s failed (attempt %d), retrying in %v: %v", w.notifier.Name(), attempt, backoff, err)
+		select {
+		case <-d.ctx.Done():
+			d.deadLetterAll(w, transitions, attempt, err)
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	d.deadLetterAll(w, transitions, attempt, err)
+}
+
+// deadLetterAll records every transition of a failed delivery
+func (d *Dispatcher) deadLetterAll(w *worker, transitions []models.Transition, attempts int, err error) {
+	for _, transition := range transitions {
+		d.deadLetter.Record(w.notifier.Name(), transition, attempts, err)
+	}
+}
+
+// Close stops accepting transitions and waits for queued deliveries until
+// the context expires, after which pending retries are abandoned
+func (d *Dispatcher) Close(ctx context.Context) {
+	for _, w := range d.workers {
+		close(w.queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
@@ -0,0 +1,378 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// EmailConfig holds the options for an SMTP notifier
+type EmailConfig struct {
+	Host               string            `json:"host"`
+	Port               int               `json:"port,omitempty"`     // Default 587, or 465 with security "tls"
+	Security           string            `json:"security,omitempty"` // "" (STARTTLS if offered), "starttls", "tls" or "none"
+	InsecureSkipVerify bool              `json:"insecure_skip_verify,omitempty"`
+	Username           string            `json:"username,omitempty"`
+	Password           *models.SecretRef `json:"password,omitempty"`
+	From               string            `json:"from"`
+	To                 []string          `json:"to,omitempty"`             // Recipients when no route matches
+	Recipients         []EmailRoute      `json:"recipients,omitempty"`     // Recipients selected by endpoint labels
+	DigestMinutes      int               `json:"digest_minutes,omitempty"` // Batch transitions into one email per period
+	SubjectTemplate    string            `json:"subject_template,omitempty"`
+	TextTemplate       string            `json:"text_template,omitempty"`
+	HTMLTemplate       string            `json:"html_template,omitempty"`
+}
+
+// EmailRoute sends transitions of endpoints carrying the match labels to its recipients
+type EmailRoute struct {
+	Match map[string]string `json:"match"`
+	To    []string          `json:"to"`
+}
+
+// EmailData is passed to the email templates
+type EmailData struct {
+	Transitions []models.Transition
+	Digest      bool // Several transitions collected over the digest period
+	Down        int  // Transitions to down
+	Recovered   int  // Transitions back up
+}
+
+const defaultSubjectTemplate = `{{if .Digest}}[health-caretaker] {{len .Transitions}} status changes ({{.Down}} down, {{.Recovered}} recovered){{else}}{{with index .Transitions 0}}[{{if .IsRecovery}}RECOVERED{{else if .Reminder}}STILL DOWN{{else}}DOWN{{end}}] {{.EndpointName}}{{end}}{{end}}`
+
+const defaultTextTemplate = `{{range .Transitions}}{{if .IsRecovery}}RECOVERED{{else if .Reminder}}STILL DOWN{{else}}DOWN{{end}}: {{.EndpointName}}
+  URL:         {{.URL}}
+  Status:      {{.From}} -> {{.To}}{{if .StatusCode}} (HTTP {{.StatusCode}}){{end}}
+  Time:        {{.Time.Format "2006-01-02 15:04:05 MST"}}
+{{- if .IsRecovery}}
+  Downtime:    {{duration .Duration}}{{end}}
+{{- if .Error}}
+  Error:       {{.Error}}{{end}}
+{{- range $name, $value := .Labels}}
+  {{$name}}: {{$value}}{{end}}
+
+{{end}}-- 
+health-caretaker
+`
+
+const defaultHTMLTemplate = `<html><body style="font-family: sans-serif">
+{{range .Transitions}}<div style="border-left: 4px solid {{if .IsRecovery}}#2eb67d{{else}}#e01e5a{{end}}; padding: 8px 12px; margin-bottom: 16px">
+<h3 style="margin: 0 0 8px 0">{{if .IsRecovery}}RECOVERED{{else if .Reminder}}STILL DOWN{{else}}DOWN{{end}}: {{.EndpointName}}</h3>
+<table cellpadding="2">
+<tr><td><b>URL</b></td><td>{{.URL}}</td></tr>
+<tr><td><b>Status</b></td><td>{{.From}} &rarr; {{.To}}{{if .StatusCode}} (HTTP {{.StatusCode}}){{end}}</td></tr>
+<tr><td><b>Time</b></td><td>{{.Time.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+{{if .IsRecovery}}<tr><td><b>Downtime</b></td><td>{{duration .Duration}}</td></tr>{{end}}
+{{if .Error}}<tr><td><b>Error</b></td><td><code>{{.Error}}</code></td></tr>{{end}}
+{{range $name, $value := .Labels}}<tr><td><b>{{$name}}</b></td><td>{{$value}}</td></tr>{{end}}
+</table>
+</div>
+{{end}}<p style="color: #888">health-caretaker</p>
+</body></html>
+`
+
+// emailFuncs are available to email templates
+var emailFuncs = map[string]interface{}{
+	"duration": formatDuration,
+}
+
+// emailNotifier sends transitions by SMTP
+type emailNotifier struct {
+	name    string
+	options EmailConfig
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// newEmail creates an SMTP notifier, parsing its templates
+func newEmail(c Config) (*emailNotifier, error) {
+	if c.Email == nil {
+		return nil, fmt.Errorf("email notifier requires an email block")
+	}
+	options := *c.Email
+	if options.Host == "" || options.From == "" {
+		return nil, fmt.Errorf("email notifier requires host and from")
+	}
+	if len(options.To) == 0 && len(options.Recipients) == 0 {
+		return nil, fmt.Errorf("email notifier requires to or recipients")
+	}
+	if options.DigestMinutes < 0 {
+		return nil, fmt.Errorf("digest_minutes must not be negative")
+	}
+	switch options.Security {
+	case "", "starttls", "none":
+		if options.Port == 0 {
+			options.Port = 587
+		}
+	case "tls":
+		if options.Port == 0 {
+			options.Port = 465
+		}
+	default:
+		return nil, fmt.Errorf("unsupported email security %q", options.Security)
+	}
+
+	n := &emailNotifier{name: c.Name, options: options}
+	var err error
+	if n.subject, err = template.New("subject").Funcs(emailFuncs).Parse(orDefault(options.SubjectTemplate, defaultSubjectTemplate)); err != nil {
+		return nil, fmt.Errorf("invalid subject_template: %v", err)
+	}
+	if n.text, err = template.New("text").Funcs(emailFuncs).Parse(orDefault(options.TextTemplate, defaultTextTemplate)); err != nil {
+		return nil, fmt.Errorf("invalid text_template: %v", err)
+	}
+	if n.html, err = htmltemplate.New("html").Funcs(emailFuncs).Parse(orDefault(options.HTMLTemplate, defaultHTMLTemplate)); err != nil {
+		return nil, fmt.Errorf("invalid html_template: %v", err)
+	}
+	return n, nil
+}
+
+// orDefault returns value, or fallback when value is empty
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Name returns the configured notifier name
+func (n *emailNotifier) Name() string {
+	return n.name
+}
+
+// Digest returns the period over which transitions are batched
+func (n *emailNotifier) Digest() time.Duration {
+	return time.Duration(n.options.DigestMinutes) * time.Minute
+}
+
+// Notify emails a single transition
+func (n *emailNotifier) Notify(ctx context.Context, transition models.Transition) error {
+	return n.NotifyBatch(ctx, []models.Transition{transition})
+}
+
+// NotifyBatch sends every recipient one email covering the transitions routed to them
+func (n *emailNotifier) NotifyBatch(ctx context.Context, transitions []models.Transition) error {
+	byRecipient := make(map[string][]models.Transition)
+	for _, transition := range transitions {
+		for _, recipient := range n.recipients(transition) {
+			byRecipient[recipient] = append(byRecipient[recipient], transition)
+		}
+	}
+
+	// Recipients that received the same transitions share one email
+	groups := make(map[string][]string)
+	batches := make(map[string][]models.Transition)
+	for recipient, batch := range byRecipient {
+		key := batchKey(batch)
+		groups[key] = append(groups[key], recipient)
+		batches[key] = batch
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		recipients := groups[key]
+		sort.Strings(recipients)
+		message, err := n.compose(recipients, batches[key], len(batches[key]) > 1)
+		if err != nil {
+			return permanent(err)
+		}
+		if err := n.send(ctx, recipients, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recipients returns the addresses for a transition: every route whose labels
+// match, or the default recipients when none does
+func (n *emailNotifier) recipients(transition models.Transition) []string {
+	var recipients []string
+	for _, route := range n.options.Recipients {
+		matches := true
+		for name, value := range route.Match {
+			if transition.Labels[name] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			recipients = append(recipients, route.To...)
+		}
+	}
+	if len(recipients) == 0 {
+		recipients = n.options.To
+	}
+
+	seen := make(map[string]bool, len(recipients))
+	unique := recipients[:0:0]
+	for _, recipient := range recipients {
+		if !seen[recipient] {
+			seen[recipient] = true
+			unique = append(unique, recipient)
+		}
+	}
+	return unique
+}
+
+// batchKey identifies a set of transitions
+func batchKey(transitions []models.Transition) string {
+	parts := make([]string, len(transitions))
+	for i, transition := range transitions {
+		parts[i] = transition.EndpointID + "/" + transition.To + "/" + strconv.FormatInt(transition.Time.UnixNano(), 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// compose renders a multipart/alternative message with text and HTML bodies
+func (n *emailNotifier) compose(recipients []string, transitions []models.Transition, digest bool) ([]byte, error) {
+	data := EmailData{Transitions: transitions, Digest: digest}
+	for _, transition := range transitions {
+		if transition.IsRecovery() {
+			data.Recovered++
+		} else {
+			data.Down++
+		}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %v", err)
+	}
+	if err := n.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render text body: %v", err)
+	}
+	if err := n.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML body: %v", err)
+	}
+
+	var msg bytes.Buffer
+	body := multipart.NewWriter(&msg)
+	header := func(name, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+	header("From", n.options.From)
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+body.Boundary())
+	msg.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+// send delivers a message over SMTP, upgrading to TLS and authenticating as configured
+func (n *emailNotifier) send(ctx context.Context, recipients []string, message []byte) error {
+	addr := net.JoinHostPort(n.options.Host, strconv.Itoa(n.options.Port))
+	tlsConfig := &tls.Config{ServerName: n.options.Host, InsecureSkipVerify: n.options.InsecureSkipVerify}
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if n.options.Security == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.options.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP handshake with %s failed: %v", addr, err)
+	}
+	defer client.Close()
+
+	if n.options.Security != "tls" && n.options.Security != "none" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("STARTTLS failed: %v", err)
+			}
+		} else if n.options.Security == "starttls" {
+			return permanent(fmt.Errorf("%s does not support STARTTLS", addr))
+		}
+	}
+
+	if n.options.Username != "" {
+		password := ""
+		if n.options.Password != nil {
+			if password, err = n.options.Password.Resolve(); err != nil {
+				return permanent(fmt.Errorf("failed to resolve SMTP password: %v", err))
+			}
+		}
+		if err := client.Auth(smtp.PlainAuth("", n.options.Username, password, n.options.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(n.options.From); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %v", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %v", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %v", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %v", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,310 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// smtpSession is what a client did on one connection to the SMTP stub
+type smtpSession struct {
+	TLS  bool
+	Auth string // Decoded AUTH PLAIN response
+	From string
+	To   []string
+	Data []byte
+}
+
+// smtpStub is a minimal SMTP server on a loopback port. It offers STARTTLS
+// when tlsConfig is set and rejects every login when rejectAuth is.
+type smtpStub struct {
+	listener   net.Listener
+	tlsConfig  *tls.Config
+	rejectAuth bool
+
+	mutex    sync.Mutex
+	sessions []smtpSession
+}
+
+func newSMTPStub(t *testing.T, tlsConfig *tls.Config, rejectAuth bool) *smtpStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	s := &smtpStub{listener: listener, tlsConfig: tlsConfig, rejectAuth: rejectAuth}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// port returns the port the stub listens on
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// received returns the sessions that ended with QUIT
+func (s *smtpStub) received() []smtpSession {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]smtpSession(nil), s.sessions...)
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	var session smtpSession
+	defer func() { conn.Close() }()
+
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) { text.PrintfLine(format, args...) }
+	reply("220 stub ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-stub")
+			if s.tlsConfig != nil && !session.TLS {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			session.TLS = true
+		case "AUTH":
+			_, response, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(response)
+			session.Auth = string(decoded)
+			if s.rejectAuth {
+				reply("535 5.7.8 authentication credentials invalid")
+			} else {
+				reply("235 2.7.0 authentication successful")
+			}
+		case "MAIL":
+			session.From = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			session.To = append(session.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			if session.Data, err = text.ReadDotBytes(); err != nil {
+				return
+			}
+			reply("250 ok")
+		case "QUIT":
+			// Recorded before replying so the session is complete when the client returns
+			s.mutex.Lock()
+			s.sessions = append(s.sessions, session)
+			s.mutex.Unlock()
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// selfSignedTLS returns a server configuration with a certificate for
+// 127.0.0.1 that clients do not trust
+func selfSignedTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp stub"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+// emailConfig returns a notifier configuration for the stub
+func emailConfig(stub *smtpStub, security string) Config {
+	return Config{
+		Name: "mail",
+		Type: "email",
+		Email: &EmailConfig{
+			Host:               "127.0.0.1",
+			Port:               stub.port(),
+			Security:           security,
+			InsecureSkipVerify: true,
+			Username:           "alerts",
+			Password:           &models.SecretRef{Env: "TEST_SMTP_PASSWORD"},
+			From:               "caretaker@example.com",
+			To:                 []string{"ops@example.com"},
+			Recipients:         []EmailRoute{{Match: map[string]string{"team": "shop"}, To: []string{"shop@example.com", "oncall@example.com"}}},
+		},
+	}
+}
+
+func TestEmailDelivery(t *testing.T) {
+	os.Setenv("TEST_SMTP_PASSWORD", "hunter2")
+	defer os.Unsetenv("TEST_SMTP_PASSWORD")
+
+	stub := newSMTPStub(t, selfSignedTLS(t), false)
+	notifier, err := New(emailConfig(stub, "starttls"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := notifier.Notify(context.Background(), downTransition()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	sessions := stub.received()
+	if len(sessions) != 1 {
+		t.Fatalf("%d SMTP sessions, want 1", len(sessions))
+	}
+	session := sessions[0]
+	if !session.TLS {
+		t.Errorf("message was sent without STARTTLS")
+	}
+	if session.Auth != "\x00alerts\x00hunter2" {
+		t.Errorf("AUTH PLAIN = %q", session.Auth)
+	}
+	if session.From != "caretaker@example.com" || strings.Join(session.To, ",") != "oncall@example.com,shop@example.com" {
+		t.Errorf("envelope = %s -> %v", session.From, session.To)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(session.Data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "[DOWN] Orders API" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if msg.Header.Get("From") != "caretaker@example.com" || msg.Header.Get("To") != "oncall@example.com, shop@example.com" {
+		t.Errorf("From/To = %q/%q", msg.Header.Get("From"), msg.Header.Get("To"))
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	for _, want := range []string{"DOWN: Orders API", "https://orders.internal/healthz", "up -> down (HTTP 503)", "Error:       status code 503", "team: shop"} {
+		if !strings.Contains(parts["text/plain"], want) {
+			t.Errorf("text body does not contain %q:\n%s", want, parts["text/plain"])
+		}
+	}
+	for _, want := range []string{"<h3", "DOWN: Orders API", "<code>status code 503</code>"} {
+		if !strings.Contains(parts["text/html"], want) {
+			t.Errorf("HTML body does not contain %q:\n%s", want, parts["text/html"])
+		}
+	}
+}
+
+func TestEmailFailures(t *testing.T) {
+	os.Setenv("TEST_SMTP_PASSWORD", "hunter2")
+	defer os.Unsetenv("TEST_SMTP_PASSWORD")
+
+	tests := []struct {
+		name       string
+		tls        bool
+		rejectAuth bool
+		security   string
+		verify     bool
+		err        string
+		permanent  bool
+	}{
+		{name: "STARTTLS required but not offered", security: "starttls", err: "does not support STARTTLS", permanent: true},
+		{name: "untrusted STARTTLS certificate", tls: true, verify: true, err: "STARTTLS failed"},
+		{name: "authentication rejected", tls: true, rejectAuth: true, err: "SMTP authentication failed: 535"},
+		{name: "authentication rejected without TLS", rejectAuth: true, security: "none", err: "SMTP authentication failed: 535"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tlsConfig *tls.Config
+			if tt.tls {
+				tlsConfig = selfSignedTLS(t)
+			}
+			stub := newSMTPStub(t, tlsConfig, tt.rejectAuth)
+			config := emailConfig(stub, tt.security)
+			config.Email.InsecureSkipVerify = !tt.verify
+			notifier, err := New(config)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = notifier.Notify(ctx, downTransition())
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Notify() error = %v, want it to contain %q", err, tt.err)
+			}
+			var perm *permanentError
+			if errors.As(err, &perm) != tt.permanent {
+				t.Errorf("permanent = %v, want %v", !tt.permanent, tt.permanent)
+			}
+			for _, session := range stub.received() {
+				if session.Data != nil {
+					t.Errorf("message was sent despite the failure")
+				}
+			}
+		})
+	}
+}
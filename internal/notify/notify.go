@@ -0,0 +1,131 @@
+// Package notify delivers endpoint status transitions to alerting destinations
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultTimeout bounds a single delivery attempt when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+// Notifier delivers a transition to one destination
+type Notifier interface {
+	// Name identifies the notifier in logs and the dead-letter log
+	Name() string
+	// Notify delivers the transition; it is retried by the dispatcher on error
+	Notify(ctx context.Context, transition models.Transition) error
+}
+
+// BatchNotifier is a Notifier that can collect transitions and deliver them together
+type BatchNotifier interface {
+	Notifier
+	// Digest returns how long transitions are collected before a batch is
+	// delivered; zero delivers every transition on its own
+	Digest() time.Duration
+	// NotifyBatch delivers several transitions at once
+	NotifyBatch(ctx context.Context, transitions []models.Transition) error
+}
+
+// Acknowledger is implemented by notifiers whose destination tracks
+// acknowledgements. Acknowledged transitions are only delivered to them.
+type Acknowledger interface {
+	// Acknowledge marks the incident opened for the outage as acknowledged
+	Acknowledge(ctx context.Context, transition models.Transition) error
+}
+
+// Runner is implemented by notifiers that need a background task, which runs
+// until the context is cancelled
+type Runner interface {
+	Run(ctx context.Context)
+}
+
+// Config configures a single notifier
+type Config struct {
+	Name         string              `json:"name"`
+	Type         string              `json:"type"`                   // "webhook", "slack", "teams", "mattermost", "email", "pagerduty", "opsgenie" or "alertmanager"
+	URL          string              `json:"url,omitempty"`          // Destination URL, or API base URL for pagerduty, opsgenie and alertmanager
+	Match        map[string]string   `json:"match,omitempty"`        // Endpoint labels a transition must carry to be sent here
+	Timeout      int                 `json:"timeout,omitempty"`      // Seconds per delivery attempt (default 10)
+	Retry        *models.RetryPolicy `json:"retry,omitempty"`        // Redelivery on failure (default 3 retries)
+	Webhook      *WebhookConfig      `json:"webhook,omitempty"`      // Options for webhook notifiers
+	Chat         *ChatConfig         `json:"chat,omitempty"`         // Options for slack, teams and mattermost notifiers
+	Email        *EmailConfig        `json:"email,omitempty"`        // Options for email notifiers
+	PagerDuty    *PagerDutyConfig    `json:"pagerduty,omitempty"`    // Options for pagerduty notifiers
+	Opsgenie     *OpsgenieConfig     `json:"opsgenie,omitempty"`     // Options for opsgenie notifiers
+	Alertmanager *AlertmanagerConfig `json:"alertmanager,omitempty"` // Options for alertmanager notifiers
+}
+
+// Matches reports whether the transition's endpoint carries every label in
+// the notifier's match set. An empty match set accepts every transition.
+func (c *Config) Matches(transition models.Transition) bool {
+	for name, value := range c.Match {
+		if transition.Labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// timeout returns the per-attempt delivery timeout
+func (c *Config) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// Validate checks the notifier configuration without contacting the destination
+func (c *Config) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if c.Retry != nil && (c.Retry.Attempts < 0 || c.Retry.Backoff < 0 || c.Retry.MaxBackoff < 0) {
+		return fmt.Errorf("retry settings must not be negative")
+	}
+
+	_, err := New(*c)
+	return err
+}
+
+// New creates the notifier for the configured type
+func New(c Config) (Notifier, error) {
+	switch c.Type {
+	case "webhook":
+		return newWebhook(c)
+	case "slack", "mattermost":
+		return newChat(c, formatSlack)
+	case "teams":
+		return newChat(c, formatTeams)
+	case "email":
+		return newEmail(c)
+	case "pagerduty":
+		return newPagerDuty(c)
+	case "opsgenie":
+		return newOpsgenie(c)
+	case "alertmanager":
+		return newAlertmanager(c)
+	case "":
+		return nil, fmt.Errorf("type is required")
+	default:
+		return nil, fmt.Errorf("unsupported notifier type %q", c.Type)
+	}
+}
+
+// permanentError marks a delivery failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+// permanent wraps err so the dispatcher does not retry it
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultOpsgenieURL is the Opsgenie API base URL; EU accounts use https://api.eu.opsgenie.com
+const DefaultOpsgenieURL = "https://api.opsgenie.com"
+
+// defaultOpsgeniePriorities maps criticality label values to Opsgenie priorities
+var defaultOpsgeniePriorities = map[string]string{
+	"critical": "P1",
+	"high":     "P1",
+	"medium":   "P3",
+	"low":      "P4",
+	"info":     "P5",
+}
+
+// OpsgenieConfig holds the options for an Opsgenie alert API notifier
+type OpsgenieConfig struct {
+	APIKey      models.SecretRef  `json:"api_key"`                // API integration key
+	PriorityMap map[string]string `json:"priority_map,omitempty"` // Overrides of the criticality to priority mapping
+	Tags        []string          `json:"tags,omitempty"`         // Tags added to every alert
+}
+
+// opsgenie creates and closes Opsgenie alerts
+type opsgenie struct {
+	name    string
+	baseURL string
+	options OpsgenieConfig
+	client  *http.Client
+}
+
+// newOpsgenie creates an Opsgenie notifier; url overrides the API base URL
+func newOpsgenie(c Config) (*opsgenie, error) {
+	if c.Opsgenie == nil {
+		return nil, fmt.Errorf("opsgenie notifier requires an opsgenie block")
+	}
+	if c.Opsgenie.APIKey.File == "" && c.Opsgenie.APIKey.Env == "" {
+		return nil, fmt.Errorf("opsgenie notifier requires an api_key")
+	}
+	baseURL, err := validateBaseURL(c, DefaultOpsgenieURL)
+	if err != nil {
+		return nil, err
+	}
+	return &opsgenie{name: c.Name, baseURL: baseURL, options: *c.Opsgenie, client: &http.Client{}}, nil
+}
+
+// Name returns the configured notifier name
+func (o *opsgenie) Name() string {
+	return o.name
+}
+
+// opsgenieAlert is a create-alert request
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+// opsgenieAction is a close- or acknowledge-alert request
+type opsgenieAction struct {
+	Source string `json:"source"`
+	User   string `json:"user,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Notify creates an alert when the endpoint goes down and closes it on recovery
+func (o *opsgenie) Notify(ctx context.Context, transition models.Transition) error {
+	apiKey, err := o.options.APIKey.Resolve()
+	if err != nil {
+		return permanent(fmt.Errorf("failed to resolve API key: %v", err))
+	}
+
+	alias := dedupKey(transition)
+	var endpoint string
+	var payload interface{}
+	if transition.IsRecovery() {
+		endpoint = o.baseURL + "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		payload = opsgenieAction{
+			Source: "health-caretaker",
+			Note:   fmt.Sprintf("%s recovered after %s", transition.EndpointName, formatDuration(transition.Duration())),
+		}
+	} else {
+		details := make(map[string]string)
+		for key, value := range alertDetails(transition) {
+			details[key] = fmt.Sprint(value)
+		}
+		tags := append([]string{}, o.options.Tags...)
+		for name, value := range transition.Labels {
+			tags = append(tags, name+":"+value)
+		}
+		sort.Strings(tags)
+
+		endpoint = o.baseURL + "/v2/alerts"
+		payload = opsgenieAlert{
+			Message:     truncate(alertSummary(transition), 130),
+			Alias:       alias,
+			Description: transition.Error,
+			Tags:        tags,
+			Details:     details,
+			Entity:      transition.EndpointName,
+			Source:      "health-caretaker",
+			Priority:    mapCriticality(transition, o.options.PriorityMap, defaultOpsgeniePriorities, "P3"),
+		}
+	}
+
+	return o.post(ctx, apiKey, endpoint, payload)
+}
+
+// Acknowledge acknowledges the alert created for the outage
+func (o *opsgenie) Acknowledge(ctx context.Context, transition models.Transition) error {
+	apiKey, err := o.options.APIKey.Resolve()
+	if err != nil {
+		return permanent(fmt.Errorf("failed to resolve API key: %v", err))
+	}
+
+	endpoint := o.baseURL + "/v2/alerts/" + url.PathEscape(dedupKey(transition)) + "/acknowledge?identifierType=alias"
+	return o.post(ctx, apiKey, endpoint, opsgenieAction{
+		Source: "health-caretaker",
+		User:   transition.AckedBy,
+		Note:   fmt.Sprintf("Acknowledged in health-caretaker by %s", orDefault(transition.AckedBy, "an unnamed user")),
+	})
+}
+
+// post sends a request to the alert API
+func (o *opsgenie) post(ctx context.Context, apiKey, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+apiKey)
+	return send(o.client, req)
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
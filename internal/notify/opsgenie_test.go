@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"health-caretaker/internal/models"
+)
+
+func TestOpsgenieAlerts(t *testing.T) {
+	os.Setenv("TEST_OPSGENIE_KEY", "api-key")
+	defer os.Unsetenv("TEST_OPSGENIE_KEY")
+
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name: "opsgenie",
+		Type: "opsgenie",
+		URL:  r.URL,
+		Opsgenie: &OpsgenieConfig{
+			APIKey: models.SecretRef{Env: "TEST_OPSGENIE_KEY"},
+			Tags:   []string{"health-caretaker"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if err := notifier.Notify(ctx, downTransition()); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := notifier.Notify(ctx, downTransition()); err != nil {
+		t.Fatalf("repeated create: %v", err)
+	}
+	if err := notifier.Notify(ctx, recoveryTransition()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	requests := r.received()
+	if len(requests) != 3 {
+		t.Fatalf("received %d requests, want 3", len(requests))
+	}
+	// The alias deduplicates the repeated alert and names it in the close
+	wantURIs := []string{
+		"/v2/alerts",
+		"/v2/alerts",
+		"/v2/alerts/health-caretaker:orders/close?identifierType=alias",
+	}
+	for i, req := range requests {
+		if req.Method != "POST" || req.URI != wantURIs[i] {
+			t.Errorf("request %d = %s %s, want POST %s", i, req.Method, req.URI, wantURIs[i])
+		}
+		if req.Header.Get("Authorization") != "GenieKey api-key" {
+			t.Errorf("request %d Authorization = %q", i, req.Header.Get("Authorization"))
+		}
+	}
+
+	var alert opsgenieAlert
+	if err := json.Unmarshal(requests[0].Body, &alert); err != nil {
+		t.Fatalf("invalid alert %s: %v", requests[0].Body, err)
+	}
+	if alert.Alias != "health-caretaker:orders" || alert.Message != "Orders API is down: status code 503" {
+		t.Errorf("alias/message = %q/%q", alert.Alias, alert.Message)
+	}
+	if alert.Priority != "P1" || alert.Entity != "Orders API" || alert.Source != "health-caretaker" {
+		t.Errorf("alert = %+v", alert)
+	}
+	wantTags := []string{"criticality:high", "health-caretaker", "team:shop"}
+	if len(alert.Tags) != len(wantTags) {
+		t.Fatalf("tags = %v, want %v", alert.Tags, wantTags)
+	}
+	for i, tag := range wantTags {
+		if alert.Tags[i] != tag {
+			t.Errorf("tags = %v, want %v", alert.Tags, wantTags)
+			break
+		}
+	}
+	if alert.Details["status_code"] != "503" || alert.Details["endpoint_id"] != "orders" {
+		t.Errorf("details = %v", alert.Details)
+	}
+
+	var repeated opsgenieAlert
+	if err := json.Unmarshal(requests[1].Body, &repeated); err != nil || repeated.Alias != alert.Alias {
+		t.Errorf("repeated alert alias = %q (%v), want %q", repeated.Alias, err, alert.Alias)
+	}
+
+	var closed opsgenieAction
+	if err := json.Unmarshal(requests[2].Body, &closed); err != nil {
+		t.Fatalf("invalid close %s: %v", requests[2].Body, err)
+	}
+	if closed.Note != "Orders API recovered after 1m30s" || closed.Source != "health-caretaker" {
+		t.Errorf("close = %+v", closed)
+	}
+}
+
+func TestOpsgenieAcknowledge(t *testing.T) {
+	os.Setenv("TEST_OPSGENIE_KEY", "api-key")
+	defer os.Unsetenv("TEST_OPSGENIE_KEY")
+
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name:     "opsgenie",
+		Type:     "opsgenie",
+		URL:      r.URL,
+		Opsgenie: &OpsgenieConfig{APIKey: models.SecretRef{Env: "TEST_OPSGENIE_KEY"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	acknowledger, ok := notifier.(Acknowledger)
+	if !ok {
+		t.Fatalf("opsgenie notifier does not acknowledge")
+	}
+
+	acked := downTransition()
+	acked.Acknowledged = true
+	acked.AckedBy = "alice"
+	if err := acknowledger.Acknowledge(context.Background(), acked); err != nil {
+		t.Fatalf("acknowledge: %v", err)
+	}
+
+	requests := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(requests))
+	}
+	if want := "/v2/alerts/health-caretaker:orders/acknowledge?identifierType=alias"; requests[0].URI != want {
+		t.Errorf("request URI = %s, want %s", requests[0].URI, want)
+	}
+	var action opsgenieAction
+	if err := json.Unmarshal(requests[0].Body, &action); err != nil {
+		t.Fatalf("invalid acknowledge %s: %v", requests[0].Body, err)
+	}
+	if action.User != "alice" || action.Source != "health-caretaker" {
+		t.Errorf("acknowledge = %+v", action)
+	}
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API base URL
+const DefaultPagerDutyURL = "https://events.pagerduty.com"
+
+// criticalityLabel is the endpoint label mapped to incident severity
+const criticalityLabel = "criticality"
+
+// defaultPagerDutySeverities maps criticality label values to PagerDuty severities
+var defaultPagerDutySeverities = map[string]string{
+	"critical": "critical",
+	"high":     "critical",
+	"medium":   "error",
+	"low":      "warning",
+	"info":     "info",
+}
+
+// PagerDutyConfig holds the options for a PagerDuty Events API v2 notifier
+type PagerDutyConfig struct {
+	RoutingKey  models.SecretRef  `json:"routing_key"`            // Integration key of the service
+	Source      string            `json:"source,omitempty"`       // Event source (default: endpoint URL)
+	SeverityMap map[string]string `json:"severity_map,omitempty"` // Overrides of the criticality to severity mapping
+}
+
+// dedupKey derives a stable incident key from the endpoint ID, so repeated
+// triggers update one incident and the recovery resolves it
+func dedupKey(transition models.Transition) string {
+	return "health-caretaker:" + transition.EndpointID
+}
+
+// mapCriticality looks up the endpoint's criticality label in the overrides,
+// then the defaults, falling back to the given value
+func mapCriticality(transition models.Transition, overrides, defaults map[string]string, fallback string) string {
+	criticality := strings.ToLower(transition.Labels[criticalityLabel])
+	if value, ok := overrides[criticality]; ok {
+		return value
+	}
+	if value, ok := defaults[criticality]; ok {
+		return value
+	}
+	return fallback
+}
+
+// alertSummary is the one-line description of a transition
+func alertSummary(transition models.Transition) string {
+	summary := fmt.Sprintf("%s is %s", transition.EndpointName, transition.To)
+	if !transition.IsRecovery() && transition.Error != "" {
+		summary += ": " + transition.Error
+	}
+	if len(summary) > 1024 {
+		summary = summary[:1024]
+	}
+	return summary
+}
+
+// alertDetails are the custom fields attached to incidents
+func alertDetails(transition models.Transition) map[string]interface{} {
+	details := map[string]interface{}{
+		"endpoint_id": transition.EndpointID,
+		"url":         transition.URL,
+		"status":      transition.To,
+		"down_since":  transition.DownSince.Format(time.RFC3339),
+	}
+	if transition.StatusCode != 0 {
+		details["status_code"] = strconv.Itoa(transition.StatusCode)
+	}
+	if transition.Error != "" {
+		details["error"] = transition.Error
+	}
+	for name, value := range transition.Labels {
+		details["label_"+name] = value
+	}
+	return details
+}
+
+// validateBaseURL checks an optional API base URL override
+func validateBaseURL(c Config, fallback string) (string, error) {
+	if c.URL == "" {
+		return fallback, nil
+	}
+	if u, err := url.ParseRequestURI(c.URL); err != nil || u.Host == "" {
+		return "", fmt.Errorf("%s notifier has an invalid url %q", c.Type, c.URL)
+	}
+	return strings.TrimRight(c.URL, "/"), nil
+}
+
+// pagerDuty triggers and resolves PagerDuty incidents
+type pagerDuty struct {
+	name    string
+	baseURL string
+	options PagerDutyConfig
+	client  *http.Client
+}
+
+// newPagerDuty creates a PagerDuty notifier; url overrides the API base URL
+func newPagerDuty(c Config) (*pagerDuty, error) {
+	if c.PagerDuty == nil {
+		return nil, fmt.Errorf("pagerduty notifier requires a pagerduty block")
+	}
+	if c.PagerDuty.RoutingKey.File == "" && c.PagerDuty.RoutingKey.Env == "" {
+		return nil, fmt.Errorf("pagerduty notifier requires a routing_key")
+	}
+	baseURL, err := validateBaseURL(c, DefaultPagerDutyURL)
+	if err != nil {
+		return nil, err
+	}
+	return &pagerDuty{name: c.Name, baseURL: baseURL, options: *c.PagerDuty, client: &http.Client{}}, nil
+}
+
+// Name returns the configured notifier name
+func (p *pagerDuty) Name() string {
+	return p.name
+}
+
+// pagerDutyEvent is an Events API v2 request
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Notify triggers an incident when the endpoint goes down and resolves it on recovery
+func (p *pagerDuty) Notify(ctx context.Context, transition models.Transition) error {
+	routingKey, err := p.options.RoutingKey.Resolve()
+	if err != nil {
+		return permanent(fmt.Errorf("failed to resolve routing key: %v", err))
+	}
+
+	event := pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "trigger",
+		DedupKey:    dedupKey(transition),
+		Client:      "health-caretaker",
+	}
+	if transition.IsRecovery() {
+		event.EventAction = "resolve"
+	} else {
+		source := p.options.Source
+		if source == "" {
+			source = transition.URL
+		}
+		event.Payload = &pagerDutyPayload{
+			Summary:       alertSummary(transition),
+			Source:        source,
+			Severity:      mapCriticality(transition, p.options.SeverityMap, defaultPagerDutySeverities, "error"),
+			Timestamp:     transition.Time.Format(time.RFC3339),
+			Component:     transition.EndpointName,
+			Group:         transition.Labels["team"],
+			CustomDetails: alertDetails(transition),
+		}
+		if u, err := url.Parse(transition.URL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			event.Links = []pagerDutyLink{{Href: transition.URL, Text: transition.EndpointName}}
+		}
+	}
+
+	return p.post(ctx, event)
+}
+
+// Acknowledge acknowledges the incident triggered for the outage
+func (p *pagerDuty) Acknowledge(ctx context.Context, transition models.Transition) error {
+	routingKey, err := p.options.RoutingKey.Resolve()
+	if err != nil {
+		return permanent(fmt.Errorf("failed to resolve routing key: %v", err))
+	}
+
+	return p.post(ctx, pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "acknowledge",
+		DedupKey:    dedupKey(transition),
+		Client:      "health-caretaker",
+	})
+}
+
+// post sends an event to the Events API
+func (p *pagerDuty) post(ctx context.Context, event pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v2/enqueue", bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(p.client, req)
+}
@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"health-caretaker/internal/models"
+)
+
+func TestPagerDutyEvents(t *testing.T) {
+	os.Setenv("TEST_PAGERDUTY_KEY", "routing-key")
+	defer os.Unsetenv("TEST_PAGERDUTY_KEY")
+
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name: "pagerduty",
+		Type: "pagerduty",
+		URL:  r.URL + "/",
+		PagerDuty: &PagerDutyConfig{
+			RoutingKey:  models.SecretRef{Env: "TEST_PAGERDUTY_KEY"},
+			SeverityMap: map[string]string{"high": "warning"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if err := notifier.Notify(ctx, downTransition()); err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	if err := notifier.Notify(ctx, downTransition()); err != nil {
+		t.Fatalf("repeated trigger: %v", err)
+	}
+	if err := notifier.Notify(ctx, recoveryTransition()); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	requests := r.received()
+	if len(requests) != 3 {
+		t.Fatalf("received %d events, want 3", len(requests))
+	}
+	events := make([]pagerDutyEvent, len(requests))
+	for i, req := range requests {
+		if req.Method != "POST" || req.URI != "/v2/enqueue" {
+			t.Errorf("event %d sent to %s %s, want POST /v2/enqueue", i, req.Method, req.URI)
+		}
+		if err := json.Unmarshal(req.Body, &events[i]); err != nil {
+			t.Fatalf("invalid event %s: %v", req.Body, err)
+		}
+		if events[i].RoutingKey != "routing-key" {
+			t.Errorf("event %d routing_key = %q", i, events[i].RoutingKey)
+		}
+		// The same key lets PagerDuty tie the events to one incident
+		if events[i].DedupKey != "health-caretaker:orders" {
+			t.Errorf("event %d dedup_key = %q", i, events[i].DedupKey)
+		}
+	}
+
+	trigger := events[0]
+	if trigger.EventAction != "trigger" || trigger.Payload == nil {
+		t.Fatalf("first event = %+v, want a trigger with a payload", trigger)
+	}
+	payload := trigger.Payload
+	if payload.Summary != "Orders API is down: status code 503" || payload.Source != "https://orders.internal/healthz" {
+		t.Errorf("summary/source = %q/%q", payload.Summary, payload.Source)
+	}
+	if payload.Severity != "warning" {
+		t.Errorf("severity = %q, want the severity_map override", payload.Severity)
+	}
+	if payload.Component != "Orders API" || payload.Group != "shop" || payload.Timestamp != "2026-03-01T12:00:00Z" {
+		t.Errorf("payload = %+v", payload)
+	}
+	if payload.CustomDetails["status_code"] != "503" || payload.CustomDetails["label_team"] != "shop" {
+		t.Errorf("custom_details = %v", payload.CustomDetails)
+	}
+	if len(trigger.Links) != 1 || trigger.Links[0].Href != "https://orders.internal/healthz" {
+		t.Errorf("links = %+v", trigger.Links)
+	}
+
+	if events[1].EventAction != "trigger" {
+		t.Errorf("second event = %+v, want a trigger of the same incident", events[1])
+	}
+	if resolve := events[2]; resolve.EventAction != "resolve" || resolve.Payload != nil {
+		t.Errorf("last event = %+v, want resolve without a payload", resolve)
+	}
+}
+
+func TestPagerDutySeverities(t *testing.T) {
+	tests := map[string]string{"critical": "critical", "high": "critical", "medium": "error", "low": "warning", "info": "info", "": "error"}
+	for criticality, severity := range tests {
+		transition := downTransition()
+		transition.Labels = map[string]string{"criticality": criticality}
+		if got := mapCriticality(transition, nil, defaultPagerDutySeverities, "error"); got != severity {
+			t.Errorf("criticality %q: severity = %q, want %q", criticality, got, severity)
+		}
+	}
+}
+
+func TestPagerDutyAcknowledge(t *testing.T) {
+	os.Setenv("TEST_PAGERDUTY_KEY", "routing-key")
+	defer os.Unsetenv("TEST_PAGERDUTY_KEY")
+
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name:      "pagerduty",
+		Type:      "pagerduty",
+		URL:       r.URL,
+		PagerDuty: &PagerDutyConfig{RoutingKey: models.SecretRef{Env: "TEST_PAGERDUTY_KEY"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	acknowledger, ok := notifier.(Acknowledger)
+	if !ok {
+		t.Fatalf("pagerduty notifier does not acknowledge")
+	}
+
+	acked := downTransition()
+	acked.Acknowledged = true
+	acked.AckedBy = "alice"
+	if err := acknowledger.Acknowledge(context.Background(), acked); err != nil {
+		t.Fatalf("acknowledge: %v", err)
+	}
+
+	var event pagerDutyEvent
+	requests := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d events, want 1", len(requests))
+	}
+	if err := json.Unmarshal(requests[0].Body, &event); err != nil {
+		t.Fatalf("invalid event %s: %v", requests[0].Body, err)
+	}
+	if event.EventAction != "acknowledge" || event.DedupKey != "health-caretaker:orders" || event.Payload != nil {
+		t.Errorf("event = %+v, want an acknowledge of the incident without a payload", event)
+	}
+}
@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/template"
+
+	"health-caretaker/internal/models"
+)
+
+// DefaultSignatureHeader carries the HMAC signature of signed webhook bodies
+const DefaultSignatureHeader = "X-Signature-256"
+
+// WebhookConfig holds the options for a generic webhook notifier
+type WebhookConfig struct {
+	Method          string            `json:"method,omitempty"`           // HTTP method (default POST)
+	Headers         map[string]string `json:"headers,omitempty"`          // Extra request headers
+	Template        string            `json:"template,omitempty"`         // text/template for the body; default is the transition as JSON
+	TemplateFile    string            `json:"template_file,omitempty"`    // File holding the body template
+	Secret          *models.SecretRef `json:"secret,omitempty"`           // Key for the HMAC-SHA256 body signature
+	SignatureHeader string            `json:"signature_header,omitempty"` // Header for the signature (default X-Signature-256)
+}
+
+// templateFuncs are available to payload templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// webhook posts transitions to an HTTP endpoint
+type webhook struct {
+	name     string
+	url      string
+	options  WebhookConfig
+	template *template.Template
+	client   *http.Client
+}
+
+// newWebhook creates a webhook notifier, parsing its payload template
+func newWebhook(c Config) (*webhook, error) {
+	if _, err := url.ParseRequestURI(c.URL); err != nil || c.URL == "" {
+		return nil, fmt.Errorf("webhook requires a valid url")
+	}
+
+	w := &webhook{name: c.Name, url: c.URL, client: &http.Client{}}
+	if c.Webhook != nil {
+		w.options = *c.Webhook
+	}
+	if w.options.Method == "" {
+		w.options.Method = http.MethodPost
+	}
+	if w.options.SignatureHeader == "" {
+		w.options.SignatureHeader = DefaultSignatureHeader
+	}
+
+	text := w.options.Template
+	if w.options.TemplateFile != "" {
+		if text != "" {
+			return nil, fmt.Errorf("webhook template and template_file are mutually exclusive")
+		}
+		data, err := os.ReadFile(w.options.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook template: %v", err)
+		}
+		text = string(data)
+	}
+	if text != "" {
+		tmpl, err := template.New(c.Name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %v", err)
+		}
+		w.template = tmpl
+	}
+
+	return w, nil
+}
+
+// Name returns the configured notifier name
+func (w *webhook) Name() string {
+	return w.name
+}
+
+// Notify renders the payload, signs it if a secret is configured and sends it
+func (w *webhook) Notify(ctx context.Context, transition models.Transition) error {
+	body, err := w.render(transition)
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.options.Method, w.url, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "health-caretaker")
+	for name, value := range w.options.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if w.options.Secret != nil {
+		secret, err := w.options.Secret.Resolve()
+		if err != nil {
+			return permanent(fmt.Errorf("failed to resolve webhook secret: %v", err))
+		}
+		req.Header.Set(w.options.SignatureHeader, Sign([]byte(secret), body))
+	}
+
+	return send(w.client, req)
+}
+
+// render produces the request body for a transition
+func (w *webhook) render(transition models.Transition) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(transition)
+	}
+
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, transition); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the "sha256=<hex>" HMAC-SHA256 signature of a body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send performs a request and treats any non-2xx response as a failure.
+// Client errors other than 408 and 429 are not retried.
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil
+	}
+
+	err = fmt.Errorf("%s responded with status %d", req.URL.Host, resp.StatusCode)
+	if snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512)); len(bytes.TrimSpace(snippet)) > 0 {
+		err = fmt.Errorf("%v: %s", err, bytes.TrimSpace(snippet))
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanent(err)
+	}
+	return err
+}
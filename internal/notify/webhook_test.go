@@ -0,0 +1,304 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// request is a request captured by a test receiver
+type request struct {
+	Method string
+	URI    string // Path and query
+	Header http.Header
+	Body   []byte
+}
+
+// receiver records the requests it gets and answers with the next of its
+// status codes, then 200 once they are used up
+type receiver struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mutex.Lock()
+		r.requests = append(r.requests, request{Method: req.Method, URI: req.URL.RequestURI(), Header: req.Header.Clone(), Body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mutex.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// received returns the requests received so far
+func (r *receiver) received() []request {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]request(nil), r.requests...)
+}
+
+// decode unmarshals the body of the only request the receiver got
+func (r *receiver) decode(t *testing.T, v interface{}) request {
+	t.Helper()
+	requests := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(requests))
+	}
+	if err := json.Unmarshal(requests[0].Body, v); err != nil {
+		t.Fatalf("invalid JSON body %s: %v", requests[0].Body, err)
+	}
+	return requests[0]
+}
+
+// downTransition is an endpoint going down
+func downTransition() models.Transition {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return models.Transition{
+		EndpointID:   "orders",
+		EndpointName: "Orders API",
+		URL:          "https://orders.internal/healthz",
+		Labels:       map[string]string{"team": "shop", "criticality": "high"},
+		From:         "up",
+		To:           "down",
+		StatusCode:   503,
+		Error:        "status code 503",
+		Time:         now,
+		DownSince:    now,
+		IncidentID:   "inc-1",
+	}
+}
+
+// recoveryTransition is the endpoint of downTransition recovering
+func recoveryTransition() models.Transition {
+	transition := downTransition()
+	transition.From, transition.To = "down", "up"
+	transition.StatusCode = 200
+	transition.Error = ""
+	transition.Time = transition.DownSince.Add(90 * time.Second)
+	return transition
+}
+
+func TestWebhookPayload(t *testing.T) {
+	os.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+	defer os.Unsetenv("TEST_WEBHOOK_SECRET")
+
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name: "hook",
+		Type: "webhook",
+		URL:  r.URL,
+		Webhook: &WebhookConfig{
+			Headers: map[string]string{"X-Team": "shop"},
+			Secret:  &models.SecretRef{Env: "TEST_WEBHOOK_SECRET"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	transition := downTransition()
+	if err := notifier.Notify(context.Background(), transition); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var got models.Transition
+	req := r.decode(t, &got)
+	if req.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", req.Method)
+	}
+	if got.EndpointID != transition.EndpointID || got.To != "down" || got.StatusCode != 503 || got.Labels["team"] != "shop" {
+		t.Errorf("body = %+v, want the transition", got)
+	}
+	if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("X-Team") != "shop" {
+		t.Errorf("headers = %v", req.Header)
+	}
+	if signature := req.Header.Get(DefaultSignatureHeader); signature != Sign([]byte("s3cret"), req.Body) {
+		t.Errorf("signature = %q, want the HMAC of the body", signature)
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	r := newReceiver(t)
+	notifier, err := New(Config{
+		Name:    "hook",
+		Type:    "webhook",
+		URL:     r.URL,
+		Webhook: &WebhookConfig{Method: http.MethodPut, Template: `{"text": {{json .EndpointName}}, "status": "{{.To}}"}`},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := notifier.Notify(context.Background(), downTransition()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var got map[string]string
+	req := r.decode(t, &got)
+	if req.Method != http.MethodPut {
+		t.Errorf("method = %s, want PUT", req.Method)
+	}
+	if got["text"] != "Orders API" || got["status"] != "down" {
+		t.Errorf("body = %v", got)
+	}
+	if req.Header.Get(DefaultSignatureHeader) != "" {
+		t.Errorf("unsigned webhook sent a signature")
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		requests     int
+		deadLettered bool
+	}{
+		{name: "5xx then success", statuses: []int{503, 502}, requests: 3},
+		{name: "429 is retried", statuses: []int{429}, requests: 2},
+		{name: "5xx until retries run out", statuses: []int{500, 500, 500}, requests: 3, deadLettered: true},
+		{name: "4xx is not retried", statuses: []int{400}, requests: 1, deadLettered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReceiver(t, tt.statuses...)
+			path := filepath.Join(t.TempDir(), "dead.jsonl")
+			deadLetter, err := NewDeadLetterLog(path)
+			if err != nil {
+				t.Fatalf("NewDeadLetterLog: %v", err)
+			}
+			defer deadLetter.Close()
+
+			d, err := NewDispatcher([]Config{{
+				Name:  "hook",
+				Type:  "webhook",
+				URL:   r.URL,
+				Retry: &models.RetryPolicy{Attempts: 2, Backoff: 0.01},
+			}}, deadLetter)
+			if err != nil {
+				t.Fatalf("NewDispatcher: %v", err)
+			}
+			d.Dispatch(downTransition())
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			d.Close(ctx)
+
+			if got := len(r.received()); got != tt.requests {
+				t.Errorf("received %d requests, want %d", got, tt.requests)
+			}
+			var letters []DeadLetter
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var letter DeadLetter
+				if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+					t.Fatalf("invalid dead letter %s: %v", scanner.Text(), err)
+				}
+				letters = append(letters, letter)
+			}
+			if tt.deadLettered != (len(letters) == 1) || len(letters) > 1 {
+				t.Fatalf("dead letters = %+v, want dead-lettered %v", letters, tt.deadLettered)
+			}
+			if tt.deadLettered && letters[0].Attempts != tt.requests {
+				t.Errorf("dead letter attempts = %d, want %d", letters[0].Attempts, tt.requests)
+			}
+		})
+	}
+}
+
+// recorder is a batch notifier that acknowledges and records its calls
+type recorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *recorder) Name() string          { return "recorder" }
+func (r *recorder) Digest() time.Duration { return 0 }
+
+func (r *recorder) record(call string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *recorder) Notify(ctx context.Context, transition models.Transition) error {
+	return r.record("notify " + transition.To)
+}
+
+func (r *recorder) NotifyBatch(ctx context.Context, transitions []models.Transition) error {
+	call := "batch"
+	for _, transition := range transitions {
+		call += " " + transition.To
+	}
+	return r.record(call)
+}
+
+func (r *recorder) Acknowledge(ctx context.Context, transition models.Transition) error {
+	return r.record("acknowledge " + transition.EndpointID)
+}
+
+func TestDispatcherAcknowledgements(t *testing.T) {
+	os.Setenv("TEST_OPSGENIE_KEY", "api-key")
+	defer os.Unsetenv("TEST_OPSGENIE_KEY")
+
+	hook := newReceiver(t)
+	genie := newReceiver(t)
+	rec := &recorder{}
+	d, err := NewDispatcher([]Config{
+		{Name: "hook", Type: "webhook", URL: hook.URL},
+		{Name: "opsgenie", Type: "opsgenie", URL: genie.URL, Opsgenie: &OpsgenieConfig{APIKey: models.SecretRef{Env: "TEST_OPSGENIE_KEY"}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	d.Add(rec, Config{Name: "recorder"})
+
+	acked := downTransition()
+	acked.Acknowledged = true
+	d.Dispatch(acked)
+	// A batch mixing acknowledgements and alerts is delivered in order,
+	// with the acknowledgements on their own
+	d.DispatchTo("recorder", []models.Transition{downTransition(), downTransition(), acked, recoveryTransition()})
+	d.Close(context.Background())
+
+	if got := len(hook.received()); got != 0 {
+		t.Errorf("webhook received %d requests for an acknowledgement, want 0", got)
+	}
+	if requests := genie.received(); len(requests) != 1 || requests[0].URI != "/v2/alerts/health-caretaker:orders/acknowledge?identifierType=alias" {
+		t.Errorf("opsgenie received %+v, want one acknowledgement", requests)
+	}
+	want := []string{"acknowledge orders", "batch down down", "acknowledge orders", "notify up"}
+	if len(rec.calls) != len(want) {
+		t.Fatalf("recorder calls = %q, want %q", rec.calls, want)
+	}
+	for i := range want {
+		if rec.calls[i] != want[i] {
+			t.Errorf("recorder calls = %q, want %q", rec.calls, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package reports computes availability reports over stored check results
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// rollingWindows are the supported trailing report windows
+var rollingWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// Range is the reporting period, [From, To)
+type Range struct {
+	Label string    `json:"label"` // Window name, calendar month or "custom"
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+}
+
+// ParseRange resolves the report period from a rolling window ("24h", "7d",
+// "30d"), a calendar month ("2006-01", UTC) or explicit from/to bounds in
+// RFC 3339 or unix seconds. Without any of them the last 24 hours are used.
+func ParseRange(window, month, from, to string, now time.Time) (Range, error) {
+	switch {
+	case month != "":
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+		}
+		if !start.Before(now) {
+			return Range{}, fmt.Errorf("month %s has not started yet", month)
+		}
+		end := start.AddDate(0, 1, 0)
+		if end.After(now) {
+			end = now
+		}
+		return Range{Label: month, From: start, To: end}, nil
+
+	case from != "" || to != "":
+		if from == "" {
+			return Range{}, fmt.Errorf("from is required for a custom range")
+		}
+		r := Range{Label: "custom", To: now}
+		var err error
+		if r.From, err = parseTime(from); err != nil {
+			return Range{}, fmt.Errorf("invalid from %q", from)
+		}
+		if to != "" {
+			if r.To, err = parseTime(to); err != nil {
+				return Range{}, fmt.Errorf("invalid to %q", to)
+			}
+		}
+		if !r.From.Before(r.To) {
+			return Range{}, fmt.Errorf("from must be before to")
+		}
+		return r, nil
+
+	default:
+		if window == "" {
+			window = "24h"
+		}
+		length, ok := rollingWindows[window]
+		if !ok {
+			return Range{}, fmt.Errorf("unsupported window %q, expected 24h, 7d or 30d", window)
+		}
+		return Range{Label: window, From: now.Add(-length), To: now}, nil
+	}
+}
+
+// parseTime parses an RFC 3339 timestamp or unix seconds
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Stats is the availability of one endpoint or label group over the range
+type Stats struct {
+	ID            string            `json:"id,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Endpoints     int               `json:"endpoints,omitempty"` // Endpoints in the group
+	Checks        int               `json:"checks"`
+	Up            int               `json:"up"`
+	Down          int               `json:"down"`
+	Maintenance   int               `json:"maintenance"`   // Checks during maintenance windows, not counted in checks
+	UptimePercent *float64          `json:"uptimePercent"` // nil without any checks in the range
+	MeanLatencyMs float64           `json:"meanLatencyMs"`
+	P95LatencyMs  float64           `json:"p95LatencyMs"`
+	CoveredFrom   *time.Time        `json:"coveredFrom,omitempty"` // First result in the range; for a group, the latest of its endpoints'
+	Partial       bool              `json:"partial"`               // Results do not reach back to the start of the range
+
+	latencies []int64
+	members   []*Stats // Endpoints of a group
+}
+
+// add accounts for a single check result. Checks during maintenance are
+// excluded, degraded checks count as up, and latency is taken from
+// successful checks only so timeouts do not skew it.
+func (s *Stats) add(result models.CheckResult) {
+	if result.Maintenance {
+		s.Maintenance++
+		return
+	}
+	s.Checks++
+	if result.Status == "down" {
+		s.Down++
+		return
+	}
+	s.Up++
+	s.latencies = append(s.latencies, result.ResponseTime)
+}
+
+// finish derives the percentages and latency figures from the counts. The
+// uptime of a group is the mean of its endpoints' uptime, so that endpoints
+// checked more often do not outweigh the others.
+func (s *Stats) finish() {
+	if s.members == nil && s.Checks > 0 {
+		uptime := 100 * float64(s.Up) / float64(s.Checks)
+		s.UptimePercent = &uptime
+	}
+	var sum float64
+	var counted int
+	for _, member := range s.members {
+		if member.UptimePercent != nil {
+			sum += *member.UptimePercent
+			counted++
+		}
+		if member.Partial {
+			s.Partial = true
+		}
+		if member.CoveredFrom != nil && (s.CoveredFrom == nil || member.CoveredFrom.After(*s.CoveredFrom)) {
+			s.CoveredFrom = member.CoveredFrom
+		}
+	}
+	if counted > 0 {
+		uptime := sum / float64(counted)
+		s.UptimePercent = &uptime
+	}
+
+	if len(s.latencies) == 0 {
+		return
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	var total int64
+	for _, latency := range s.latencies {
+		total += latency
+	}
+	s.MeanLatencyMs = math.Round(100*float64(total)/float64(len(s.latencies))) / 100
+
+	// Nearest-rank percentile
+	rank := int(math.Ceil(0.95*float64(len(s.latencies)))) - 1
+	s.P95LatencyMs = float64(s.latencies[rank])
+}
+
+// UptimeReport is the availability of every endpoint and, when grouped, of
+// every label group over a range
+type UptimeReport struct {
+	Range     Range    `json:"range"`
+	GroupBy   string   `json:"groupBy,omitempty"`
+	Endpoints []*Stats `json:"endpoints"`
+	Groups    []*Stats `json:"groups,omitempty"`
+	Partial   bool     `json:"partial"` // Some endpoint's results do not cover the whole range
+}
+
+// ResultSource loads the check results of an endpoint within [from, to]
+type ResultSource func(id string, from, to time.Time) ([]models.CheckResult, error)
+
+// Uptime computes the report for the endpoints over the range. With groupBy
+// set, endpoints are also aggregated by the value of that label. An endpoint
+// whose first result in the range comes later than two check intervals after
+// its start is marked partial, as when older results were pruned or only the
+// in-memory history is available.
+func Uptime(endpoints []*models.Endpoint, source ResultSource, r Range, groupBy string) (*UptimeReport, error) {
+	report := &UptimeReport{Range: r, GroupBy: groupBy, Endpoints: []*Stats{}}
+	groups := make(map[string]*Stats)
+
+	for _, endpoint := range endpoints {
+		results, err := source(endpoint.ID, r.From, r.To)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load results for %s: %v", endpoint.ID, err)
+		}
+
+		stats := &Stats{ID: endpoint.ID, Name: endpoint.Name, Labels: endpoint.Labels}
+		var group *Stats
+		if groupBy != "" {
+			value := endpoint.Labels[groupBy]
+			if group = groups[value]; group == nil {
+				group = &Stats{Group: value, members: []*Stats{}}
+				groups[value] = group
+			}
+			group.Endpoints++
+			group.members = append(group.members, stats)
+		}
+
+		for _, result := range results {
+			if !result.Timestamp.Before(r.To) || result.Timestamp.Before(r.From) {
+				continue
+			}
+			if stats.CoveredFrom == nil {
+				first := result.Timestamp
+				stats.CoveredFrom = &first
+			}
+			stats.add(result)
+			if group != nil {
+				group.add(result)
+			}
+		}
+		tolerance := 2 * endpoint.IntervalDuration()
+		stats.Partial = stats.CoveredFrom == nil || stats.CoveredFrom.After(r.From.Add(tolerance))
+		if stats.Partial {
+			report.Partial = true
+		}
+
+		stats.finish()
+		report.Endpoints = append(report.Endpoints, stats)
+	}
+
+	for _, group := range groups {
+		group.finish()
+		report.Groups = append(report.Groups, group)
+	}
+
+	sort.Slice(report.Endpoints, func(i, j int) bool {
+		if report.Endpoints[i].Name != report.Endpoints[j].Name {
+			return report.Endpoints[i].Name < report.Endpoints[j].Name
+		}
+		return report.Endpoints[i].ID < report.Endpoints[j].ID
+	})
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Group < report.Groups[j].Group })
+
+	return report, nil
+}
+
+// WriteCSV writes one row per endpoint followed by one row per group
+func (r *UptimeReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"scope", "id", "name", "group", "from", "to", "checks", "up", "down", "maintenance", "uptime_percent", "mean_latency_ms", "p95_latency_ms", "covered_from", "partial"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	from := r.Range.From.UTC().Format(time.RFC3339)
+	to := r.Range.To.UTC().Format(time.RFC3339)
+	row := func(scope string, s *Stats) []string {
+		uptime := ""
+		if s.UptimePercent != nil {
+			uptime = strconv.FormatFloat(*s.UptimePercent, 'f', 3, 64)
+		}
+		coveredFrom := ""
+		if s.CoveredFrom != nil {
+			coveredFrom = s.CoveredFrom.UTC().Format(time.RFC3339)
+		}
+		group := s.Group
+		if scope == "endpoint" && r.GroupBy != "" {
+			group = s.Labels[r.GroupBy]
+		}
+		return []string{
+			scope, s.ID, s.Name, group, from, to,
+			strconv.Itoa(s.Checks), strconv.Itoa(s.Up), strconv.Itoa(s.Down), strconv.Itoa(s.Maintenance), uptime,
+			strconv.FormatFloat(s.MeanLatencyMs, 'f', -1, 64),
+			strconv.FormatFloat(s.P95LatencyMs, 'f', -1, 64),
+			coveredFrom, strconv.FormatBool(s.Partial),
+		}
+	}
+
+	for _, s := range r.Endpoints {
+		if err := writer.Write(row("endpoint", s)); err != nil {
+			return err
+		}
+	}
+	for _, s := range r.Groups {
+		if err := writer.Write(row("group", s)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Filename suggests a download name for the report
+func (r *UptimeReport) Filename(ext string) string {
+	name := "uptime-" + strings.NewReplacer(":", "", " ", "-").Replace(r.Range.Label)
+	return name + "." + ext
+}
@@ -0,0 +1,235 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                    string
+		window, month, from, to string
+		want                    Range
+		err                     string
+	}{
+		{
+			name: "default window",
+			want: Range{Label: "24h", From: now.Add(-24 * time.Hour), To: now},
+		},
+		{
+			name:   "rolling window",
+			window: "7d",
+			want:   Range{Label: "7d", From: now.AddDate(0, 0, -7), To: now},
+		},
+		{
+			name:  "past month",
+			month: "2026-02",
+			want:  Range{Label: "2026-02", From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "current month ends now",
+			month: "2026-03",
+			want:  Range{Label: "2026-03", From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: now},
+		},
+		{name: "month not yet started", month: "2026-04", err: "month 2026-04 has not started yet"},
+		{name: "invalid month", month: "March", err: `invalid month "March"`},
+		{
+			name: "RFC 3339 bounds",
+			from: "2026-03-01T00:00:00Z",
+			to:   "2026-03-02T00:00:00Z",
+			want: Range{Label: "custom", From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "unix seconds up to now",
+			from: "1772323200",
+			want: Range{Label: "custom", From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: now},
+		},
+		{name: "swapped bounds", from: "2026-03-02T00:00:00Z", to: "2026-03-01T00:00:00Z", err: "from must be before to"},
+		{name: "to without from", to: "2026-03-01T00:00:00Z", err: "from is required"},
+		{name: "unsupported window", window: "1h", err: `unsupported window "1h"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRange(tt.window, tt.month, tt.from, tt.to, now)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseRange() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRange() error = %v", err)
+			}
+			if got.Label != tt.want.Label || !got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) {
+				t.Errorf("ParseRange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestP95Latency(t *testing.T) {
+	tests := []struct {
+		n    int
+		want float64
+	}{
+		{n: 1, want: 1},
+		{n: 20, want: 19},
+		{n: 21, want: 20},
+	}
+
+	for _, tt := range tests {
+		s := &Stats{}
+		// Latencies 1..n in reverse, so that finish has to sort them
+		for i := tt.n; i > 0; i-- {
+			s.add(models.CheckResult{Status: "up", ResponseTime: int64(i)})
+		}
+		s.finish()
+		if s.P95LatencyMs != tt.want {
+			t.Errorf("n = %d: p95 = %v, want %v", tt.n, s.P95LatencyMs, tt.want)
+		}
+	}
+}
+
+// results returns a source serving fixed results by endpoint ID
+func results(byID map[string][]models.CheckResult) ResultSource {
+	return func(id string, from, to time.Time) ([]models.CheckResult, error) {
+		return byID[id], nil
+	}
+}
+
+// checks returns one result per minute from start with the given statuses
+func checks(start time.Time, statuses ...string) []models.CheckResult {
+	results := make([]models.CheckResult, len(statuses))
+	for i, status := range statuses {
+		results[i] = models.CheckResult{Timestamp: start.Add(time.Duration(i) * time.Minute), Status: status, ResponseTime: 100}
+	}
+	return results
+}
+
+func TestUptime(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	r := Range{Label: "custom", From: from, To: from.Add(time.Hour)}
+	endpoint := func(id, team string) *models.Endpoint {
+		return &models.Endpoint{ID: id, Name: id, Interval: 60, Labels: map[string]string{"team": team}}
+	}
+	endpoints := []*models.Endpoint{endpoint("orders", "shop"), endpoint("cart", "shop"), endpoint("idle", "shop"), endpoint("search", "platform")}
+
+	maintenance := checks(from.Add(4*time.Minute), "down", "down")
+	for i := range maintenance {
+		maintenance[i].Maintenance = true
+	}
+	source := results(map[string][]models.CheckResult{
+		// 3 of 4 up; the checks during maintenance are left out
+		"orders": append(checks(from, "up", "down", "degraded", "up"), maintenance...),
+		// Checked ten times as often as orders, all up
+		"cart": checks(from, strings.Fields(strings.Repeat("up ", 40))...),
+		// Results outside the range are ignored
+		"search": append(checks(from.Add(-time.Minute), "down"), checks(from.Add(time.Hour), "down")...),
+	})
+
+	report, err := Uptime(endpoints, source, r, "team")
+	if err != nil {
+		t.Fatalf("Uptime() error = %v", err)
+	}
+
+	stats := make(map[string]*Stats)
+	for _, s := range report.Endpoints {
+		stats[s.ID] = s
+	}
+	orders := stats["orders"]
+	if orders.Checks != 4 || orders.Up != 3 || orders.Down != 1 || orders.Maintenance != 2 {
+		t.Errorf("orders checks/up/down/maintenance = %d/%d/%d/%d, want 4/3/1/2", orders.Checks, orders.Up, orders.Down, orders.Maintenance)
+	}
+	if orders.UptimePercent == nil || *orders.UptimePercent != 75 {
+		t.Errorf("orders uptime = %v, want 75", orders.UptimePercent)
+	}
+	if stats["idle"].UptimePercent != nil || stats["search"].UptimePercent != nil {
+		t.Errorf("endpoints without checks in the range have an uptime")
+	}
+
+	if len(report.Groups) != 2 || report.Groups[0].Group != "platform" || report.Groups[1].Group != "shop" {
+		t.Fatalf("groups = %+v, want platform and shop", report.Groups)
+	}
+	shop := report.Groups[1]
+	// The mean of orders and cart, not of their checks; idle has none and
+	// is left out
+	if shop.Endpoints != 3 || shop.UptimePercent == nil || *shop.UptimePercent != 87.5 {
+		t.Errorf("shop endpoints = %d, uptime = %v; want 3 endpoints at 87.5", shop.Endpoints, shop.UptimePercent)
+	}
+	if platform := report.Groups[0]; platform.UptimePercent != nil {
+		t.Errorf("platform uptime = %v, want none", *platform.UptimePercent)
+	}
+}
+
+func TestUptimePartial(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	r := Range{Label: "custom", From: from, To: from.Add(time.Hour)}
+	tests := []struct {
+		name    string
+		first   time.Duration // Offset of the first result from the start of the range
+		none    bool
+		partial bool
+	}{
+		{name: "first result at the start", first: 0},
+		{name: "within two intervals", first: 2 * time.Minute},
+		{name: "later than two intervals", first: 2*time.Minute + time.Second, partial: true},
+		{name: "no results", none: true, partial: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byID := map[string][]models.CheckResult{}
+			if !tt.none {
+				byID["orders"] = checks(from.Add(tt.first), "up")
+			}
+			endpoints := []*models.Endpoint{{ID: "orders", Name: "Orders", Interval: 60}}
+			report, err := Uptime(endpoints, results(byID), r, "")
+			if err != nil {
+				t.Fatalf("Uptime() error = %v", err)
+			}
+			if report.Endpoints[0].Partial != tt.partial || report.Partial != tt.partial {
+				t.Errorf("partial = %v (report %v), want %v", report.Endpoints[0].Partial, report.Partial, tt.partial)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	r := Range{Label: "custom", From: from, To: from.Add(time.Hour)}
+	endpoints := []*models.Endpoint{{ID: "orders", Name: "Orders", Interval: 60, Labels: map[string]string{"team": "shop"}}}
+	report, err := Uptime(endpoints, results(map[string][]models.CheckResult{"orders": checks(from, "up", "down")}), r, "team")
+	if err != nil {
+		t.Fatalf("Uptime() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"scope", "id", "name", "group", "from", "to", "checks", "up", "down", "maintenance", "uptime_percent", "mean_latency_ms", "p95_latency_ms", "covered_from", "partial"},
+		{"endpoint", "orders", "Orders", "shop", "2026-03-01T00:00:00Z", "2026-03-01T01:00:00Z", "2", "1", "1", "0", "50.000", "100", "100", "2026-03-01T00:00:00Z", "false"},
+		{"group", "", "", "shop", "2026-03-01T00:00:00Z", "2026-03-01T01:00:00Z", "2", "1", "1", "0", "50.000", "100", "100", "2026-03-01T00:00:00Z", "false"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("CSV has %d rows, want %d:\n%s", len(rows), len(want), buf.String())
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"health-caretaker/internal/models"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	endpointsBucket = []byte("endpoints")
+	resultsBucket   = []byte("results") // One nested bucket per endpoint, keyed by timestamp and sequence number
+	incidentsBucket = []byte("incidents")
+	silencesBucket  = []byte("silences")
+	windowsBucket   = []byte("maintenance")
+)
+
+// BoltStore is a Store backed by an embedded bbolt database file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens or creates the database at path
+func NewBoltStore(path string) (*BoltStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("storage path is required")
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %v", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{endpointsBucket, resultsBucket, incidentsBucket, silencesBucket, windowsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise database: %v", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// SaveEndpoint creates or replaces an endpoint definition
+func (s *BoltStore) SaveEndpoint(endpoint *models.Endpoint) error {
+	data, err := json.Marshal(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal endpoint: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(endpointsBucket).Put([]byte(endpoint.ID), data)
+	})
+}
+
+// DeleteEndpoint removes an endpoint together with its check results
+func (s *BoltStore) DeleteEndpoint(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(endpointsBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		results := tx.Bucket(resultsBucket)
+		if results.Bucket([]byte(id)) == nil {
+			return nil
+		}
+		return results.DeleteBucket([]byte(id))
+	})
+}
+
+// Endpoints returns all stored endpoint definitions
+func (s *BoltStore) Endpoints() ([]*models.Endpoint, error) {
+	var endpoints []*models.Endpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(endpointsBucket).ForEach(func(k, v []byte) error {
+			var endpoint models.Endpoint
+			if err := json.Unmarshal(v, &endpoint); err != nil {
+				return fmt.Errorf("failed to parse endpoint %s: %v", k, err)
+			}
+			endpoints = append(endpoints, &endpoint)
+			return nil
+		})
+	})
+	return endpoints, err
+}
+
+// RenameEndpoint moves an endpoint and its check results to a new ID,
+// updating the incidents, silences and maintenance windows that refer to it
+func (s *BoltStore) RenameEndpoint(oldID, newID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		endpoints := tx.Bucket(endpointsBucket)
+		if data := endpoints.Get([]byte(oldID)); data != nil {
+			var endpoint models.Endpoint
+			if err := json.Unmarshal(data, &endpoint); err != nil {
+				return fmt.Errorf("failed to parse endpoint %s: %v", oldID, err)
+			}
+			endpoint.ID = newID
+			updated, err := json.Marshal(&endpoint)
+			if err != nil {
+				return fmt.Errorf("failed to marshal endpoint: %v", err)
+			}
+			if err := endpoints.Put([]byte(newID), updated); err != nil {
+				return err
+			}
+			if err := endpoints.Delete([]byte(oldID)); err != nil {
+				return err
+			}
+		}
+
+		results := tx.Bucket(resultsBucket)
+		if old := results.Bucket([]byte(oldID)); old != nil {
+			moved, err := results.CreateBucketIfNotExists([]byte(newID))
+			if err != nil {
+				return err
+			}
+			err = old.ForEach(func(k, v []byte) error {
+				return moved.Put(append([]byte(nil), k...), append([]byte(nil), v...))
+			})
+			if err != nil {
+				return err
+			}
+			// Later results must not reuse the sequence numbers of the moved ones
+			if old.Sequence() > moved.Sequence() {
+				if err := moved.SetSequence(old.Sequence()); err != nil {
+					return err
+				}
+			}
+			if err := results.DeleteBucket([]byte(oldID)); err != nil {
+				return err
+			}
+		}
+
+		err := updateRecords(tx.Bucket(incidentsBucket), func() interface{} { return &models.Incident{} }, func(record interface{}) bool {
+			incident := record.(*models.Incident)
+			if incident.EndpointID != oldID {
+				return false
+			}
+			incident.EndpointID = newID
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		err = updateRecords(tx.Bucket(silencesBucket), func() interface{} { return &models.Silence{} }, func(record interface{}) bool {
+			silence := record.(*models.Silence)
+			if silence.EndpointID != oldID {
+				return false
+			}
+			silence.EndpointID = newID
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		return updateRecords(tx.Bucket(windowsBucket), func() interface{} { return &models.MaintenanceWindow{} }, func(record interface{}) bool {
+			window := record.(*models.MaintenanceWindow)
+			changed := false
+			for i, id := range window.EndpointIDs {
+				if id == oldID {
+					window.EndpointIDs[i] = newID
+					changed = true
+				}
+			}
+			return changed
+		})
+	})
+}
+
+// updateRecords decodes every JSON record of a bucket into a value from
+// newRecord and saves the records that update changes
+func updateRecords(bucket *bolt.Bucket, newRecord func() interface{}, update func(record interface{}) bool) error {
+	changed := make(map[string][]byte)
+	err := bucket.ForEach(func(k, v []byte) error {
+		record := newRecord()
+		if err := json.Unmarshal(v, record); err != nil {
+			return fmt.Errorf("failed to parse record %s: %v", k, err)
+		}
+		if !update(record) {
+			return nil
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record %s: %v", k, err)
+		}
+		changed[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Records are written after iterating, which must not modify the bucket
+	for key, data := range changed {
+		if err := bucket.Put([]byte(key), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendResult stores a check result of an endpoint. Concurrent appends are
+// batched into a single transaction.
+func (s *BoltStore) AppendResult(endpointID string, result models.CheckResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %v", err)
+	}
+
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(resultsBucket).CreateBucketIfNotExists([]byte(endpointID))
+		if err != nil {
+			return err
+		}
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(resultKey(result.Timestamp, sequence), data)
+	})
+}
+
+// Results returns the results of an endpoint within [from, to], oldest first
+func (s *BoltStore) Results(endpointID string, from, to time.Time, limit int) ([]models.CheckResult, error) {
+	results := []models.CheckResult{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resultsBucket).Bucket([]byte(endpointID))
+		if bucket == nil {
+			return nil
+		}
+
+		// Walk backwards from the end of the range so a limit only reads
+		// the entries it returns
+		c := bucket.Cursor()
+		var k, v []byte
+		if to.IsZero() {
+			k, v = c.Last()
+		} else {
+			// Keys of results at to are longer than its timestamp key, so
+			// the range ends before the timestamp key of the next nanosecond
+			end := timeKey(to.Add(time.Nanosecond))
+			if k, v = c.Seek(end); k == nil {
+				k, v = c.Last()
+			}
+			for k != nil && string(k) >= string(end) {
+				k, v = c.Prev()
+			}
+		}
+
+		start := timeKey(from)
+		for ; k != nil; k, v = c.Prev() {
+			if !from.IsZero() && string(k) < string(start) {
+				break
+			}
+			var result models.CheckResult
+			if err := json.Unmarshal(v, &result); err != nil {
+				return fmt.Errorf("failed to parse result: %v", err)
+			}
+			results = append(results, result)
+			if limit > 0 && len(results) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results, nil
+}
+
+// SaveIncident creates or replaces an incident
+func (s *BoltStore) SaveIncident(incident *models.Incident) error {
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return fmt.Errorf("failed to marshal incident: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(incidentsBucket).Put([]byte(incident.ID), data)
+	})
+}
+
+// Incident returns a stored incident, or nil if it does not exist
+func (s *BoltStore) Incident(id string) (*models.Incident, error) {
+	var incident *models.Incident
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(incidentsBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		incident = &models.Incident{}
+		if err := json.Unmarshal(data, incident); err != nil {
+			return fmt.Errorf("failed to parse incident %s: %v", id, err)
+		}
+		return nil
+	})
+	return incident, err
+}
+
+// Incidents returns all stored incidents
+func (s *BoltStore) Incidents() ([]*models.Incident, error) {
+	var incidents []*models.Incident
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(incidentsBucket).ForEach(func(k, v []byte) error {
+			var incident models.Incident
+			if err := json.Unmarshal(v, &incident); err != nil {
+				return fmt.Errorf("failed to parse incident %s: %v", k, err)
+			}
+			incidents = append(incidents, &incident)
+			return nil
+		})
+	})
+	return incidents, err
+}
+
+// SaveSilence creates or replaces a silence
+func (s *BoltStore) SaveSilence(silence *models.Silence) error {
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal silence: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(silencesBucket).Put([]byte(silence.ID), data)
+	})
+}
+
+// Silences returns all stored silences
+func (s *BoltStore) Silences() ([]*models.Silence, error) {
+	var silences []*models.Silence
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(silencesBucket).ForEach(func(k, v []byte) error {
+			var silence models.Silence
+			if err := json.Unmarshal(v, &silence); err != nil {
+				return fmt.Errorf("failed to parse silence %s: %v", k, err)
+			}
+			silences = append(silences, &silence)
+			return nil
+		})
+	})
+	return silences, err
+}
+
+// SaveMaintenanceWindow creates or replaces a maintenance window
+func (s *BoltStore) SaveMaintenanceWindow(window *models.MaintenanceWindow) error {
+	data, err := json.Marshal(window)
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance window: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(windowsBucket).Put([]byte(window.ID), data)
+	})
+}
+
+// DeleteMaintenanceWindow removes a maintenance window
+func (s *BoltStore) DeleteMaintenanceWindow(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(windowsBucket).Delete([]byte(id))
+	})
+}
+
+// MaintenanceWindows returns all stored maintenance windows
+func (s *BoltStore) MaintenanceWindows() ([]*models.MaintenanceWindow, error) {
+	var windows []*models.MaintenanceWindow
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(windowsBucket).ForEach(func(k, v []byte) error {
+			var window models.MaintenanceWindow
+			if err := json.Unmarshal(v, &window); err != nil {
+				return fmt.Errorf("failed to parse maintenance window %s: %v", k, err)
+			}
+			windows = append(windows, &window)
+			return nil
+		})
+	})
+	return windows, err
+}
+
+// Prune drops check results, resolved incidents and expired silences older than before
+func (s *BoltStore) Prune(before time.Time) error {
+	cutoff := timeKey(before)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		results := tx.Bucket(resultsBucket)
+		err := results.ForEach(func(name, _ []byte) error {
+			c := results.Bucket(name).Cursor()
+			for k, _ := c.First(); k != nil && string(k) < string(cutoff); k, _ = c.First() {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		incidents := tx.Bucket(incidentsBucket)
+		var expired [][]byte
+		err = incidents.ForEach(func(k, v []byte) error {
+			var incident models.Incident
+			if err := json.Unmarshal(v, &incident); err != nil {
+				return nil
+			}
+			if incident.ResolvedAt != nil && incident.ResolvedAt.Before(before) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := incidents.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		silences := tx.Bucket(silencesBucket)
+		expired = nil
+		err = silences.ForEach(func(k, v []byte) error {
+			var silence models.Silence
+			if err := json.Unmarshal(v, &silence); err != nil {
+				return nil
+			}
+			if silence.EndsAt.Before(before) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := silences.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		windows := tx.Bucket(windowsBucket)
+		expired = nil
+		err = windows.ForEach(func(k, v []byte) error {
+			var window models.MaintenanceWindow
+			if err := json.Unmarshal(v, &window); err != nil {
+				return nil
+			}
+			if !window.IsRecurring() && window.EndsAt != nil && window.EndsAt.Before(before) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := windows.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close releases the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// timeKey encodes a timestamp so that keys sort chronologically
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// resultKey is the key of a check result: its timestamp key followed by a
+// sequence number, so that results with the same timestamp are all kept.
+// Keys written without the sequence number by earlier versions sort before
+// those with it at the same timestamp.
+func resultKey(t time.Time, sequence uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], sequence)
+	return key
+}
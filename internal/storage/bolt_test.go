@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"health-caretaker/internal/models"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// base is the time of the first result stored by the tests
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newStore opens a store in a temporary directory, closed when the test ends
+func newStore(t *testing.T) *BoltStore {
+	t.Helper()
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// minute returns the time n minutes after base
+func minute(n int) time.Time {
+	return base.Add(time.Duration(n) * time.Minute)
+}
+
+// appendResult stores a result at the given minute, named by its error text
+func appendResult(t *testing.T, store *BoltStore, id, name string, n int) {
+	t.Helper()
+	if err := store.AppendResult(id, models.CheckResult{Timestamp: minute(n), Status: "up", Error: name}); err != nil {
+		t.Fatalf("AppendResult: %v", err)
+	}
+}
+
+// names returns the names of results, joined in their order
+func names(results []models.CheckResult) string {
+	texts := make([]string, len(results))
+	for i, result := range results {
+		texts[i] = result.Error
+	}
+	return strings.Join(texts, " ")
+}
+
+func TestResultsRange(t *testing.T) {
+	store := newStore(t)
+	appendResult(t, store, "orders", "a", 0)
+	appendResult(t, store, "orders", "b", 1)
+	appendResult(t, store, "orders", "c1", 2)
+	appendResult(t, store, "orders", "c2", 2)
+	appendResult(t, store, "orders", "d", 3)
+	appendResult(t, store, "orders", "e", 4)
+	// A result stored by an earlier version, keyed by its timestamp only
+	legacy, _ := json.Marshal(models.CheckResult{Timestamp: minute(2), Status: "up", Error: "c0"})
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(resultsBucket).Bucket([]byte("orders")).Put(timeKey(minute(2)), legacy)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		from, to time.Time
+		limit    int
+		want     string
+	}{
+		{name: "everything", id: "orders", want: "a b c0 c1 c2 d e"},
+		{name: "inclusive bounds", id: "orders", from: minute(1), to: minute(3), want: "b c0 c1 c2 d"},
+		{name: "bounds on a shared timestamp", id: "orders", from: minute(2), to: minute(2), want: "c0 c1 c2"},
+		{name: "until", id: "orders", to: minute(1), want: "a b"},
+		{name: "between results", id: "orders", from: minute(1).Add(time.Second), to: minute(2).Add(-time.Second)},
+		{name: "newest within limit", id: "orders", to: minute(3), limit: 3, want: "c1 c2 d"},
+		{name: "after the last", id: "orders", from: minute(5)},
+		{name: "before the first", id: "orders", to: minute(-1)},
+		{name: "unknown endpoint", id: "search"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := store.Results(tt.id, tt.from, tt.to, tt.limit)
+			if err != nil {
+				t.Fatalf("Results() error = %v", err)
+			}
+			if got := names(results); got != tt.want {
+				t.Errorf("Results() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store := newStore(t)
+	appendResult(t, store, "orders", "old", 0)
+	appendResult(t, store, "orders", "cutoff", 2)
+	appendResult(t, store, "orders", "new", 3)
+	appendResult(t, store, "search", "old", 1)
+
+	resolved := minute(1)
+	for _, incident := range []*models.Incident{
+		{ID: "resolved-old", EndpointID: "orders", StartedAt: minute(0), ResolvedAt: &resolved},
+		{ID: "open", EndpointID: "orders", StartedAt: minute(0)},
+	} {
+		if err := store.SaveIncident(incident); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	for _, silence := range []*models.Silence{
+		{ID: "expired", EndpointID: "orders", StartsAt: minute(0), EndsAt: minute(1)},
+		{ID: "active", EndpointID: "orders", StartsAt: minute(0), EndsAt: minute(10)},
+	} {
+		if err := store.SaveSilence(silence); err != nil {
+			t.Fatalf("SaveSilence: %v", err)
+		}
+	}
+	start, end := minute(0), minute(1)
+	for _, window := range []*models.MaintenanceWindow{
+		{ID: "ended", EndpointIDs: []string{"orders"}, StartsAt: &start, EndsAt: &end},
+		{ID: "recurring", EndpointIDs: []string{"orders"}, Cron: "0 2 * * *", Duration: "1h"},
+	} {
+		if err := store.SaveMaintenanceWindow(window); err != nil {
+			t.Fatalf("SaveMaintenanceWindow: %v", err)
+		}
+	}
+
+	if err := store.Prune(minute(2)); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	results, err := store.Results("orders", time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(results); got != "cutoff new" {
+		t.Errorf("orders results = %q, want cutoff and new", got)
+	}
+	if results, _ := store.Results("search", time.Time{}, time.Time{}, 0); len(results) != 0 {
+		t.Errorf("search results = %q, want none", names(results))
+	}
+	if incident, _ := store.Incident("resolved-old"); incident != nil {
+		t.Errorf("incident resolved before the cutoff was kept")
+	}
+	if incident, _ := store.Incident("open"); incident == nil {
+		t.Errorf("open incident was pruned")
+	}
+	if silences, _ := store.Silences(); len(silences) != 1 || silences[0].ID != "active" {
+		t.Errorf("silences = %+v, want the active one", silences)
+	}
+	if windows, _ := store.MaintenanceWindows(); len(windows) != 1 || windows[0].ID != "recurring" {
+		t.Errorf("windows = %+v, want the recurring one", windows)
+	}
+}
+
+func TestRenameEndpoint(t *testing.T) {
+	store := newStore(t)
+	if err := store.SaveEndpoint(&models.Endpoint{ID: "orders", Name: "Orders", URL: "https://orders.internal/healthz"}); err != nil {
+		t.Fatalf("SaveEndpoint: %v", err)
+	}
+	appendResult(t, store, "orders", "a", 0)
+	appendResult(t, store, "orders", "b", 1)
+	if err := store.SaveIncident(&models.Incident{ID: "inc-1", EndpointID: "orders", StartedAt: minute(0)}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	if err := store.SaveSilence(&models.Silence{ID: "silence-1", EndpointID: "orders", EndsAt: minute(10)}); err != nil {
+		t.Fatalf("SaveSilence: %v", err)
+	}
+	if err := store.SaveMaintenanceWindow(&models.MaintenanceWindow{ID: "window-1", EndpointIDs: []string{"search", "orders"}, Cron: "0 2 * * *", Duration: "1h"}); err != nil {
+		t.Fatalf("SaveMaintenanceWindow: %v", err)
+	}
+
+	if err := store.RenameEndpoint("orders", "orders-api"); err != nil {
+		t.Fatalf("RenameEndpoint() error = %v", err)
+	}
+
+	endpoints, err := store.Endpoints()
+	if err != nil || len(endpoints) != 1 || endpoints[0].ID != "orders-api" || endpoints[0].Name != "Orders" {
+		t.Errorf("endpoints = %+v (%v), want orders under its new ID", endpoints, err)
+	}
+	if results, _ := store.Results("orders", time.Time{}, time.Time{}, 0); len(results) != 0 {
+		t.Errorf("results left under the old ID: %q", names(results))
+	}
+	// A result with the timestamp of a moved one is stored next to it
+	appendResult(t, store, "orders-api", "b2", 1)
+	results, err := store.Results("orders-api", time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(results); got != "a b b2" {
+		t.Errorf("results under the new ID = %q, want a, b and b2", got)
+	}
+	if incident, _ := store.Incident("inc-1"); incident == nil || incident.EndpointID != "orders-api" {
+		t.Errorf("incident = %+v, want it moved to the new ID", incident)
+	}
+	if silences, _ := store.Silences(); len(silences) != 1 || silences[0].EndpointID != "orders-api" {
+		t.Errorf("silences = %+v, want it moved to the new ID", silences)
+	}
+	windows, _ := store.MaintenanceWindows()
+	if len(windows) != 1 || len(windows[0].EndpointIDs) != 2 || windows[0].EndpointIDs[0] != "search" || windows[0].EndpointIDs[1] != "orders-api" {
+		t.Errorf("windows = %+v, want the endpoint IDs updated", windows)
+	}
+}
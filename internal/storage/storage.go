@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"health-caretaker/internal/models"
+)
+
+// Store persists endpoint definitions, check results and incidents across restarts
+type Store interface {
+	// SaveEndpoint creates or replaces an endpoint definition
+	SaveEndpoint(endpoint *models.Endpoint) error
+	// DeleteEndpoint removes an endpoint together with its check results
+	DeleteEndpoint(id string) error
+	// Endpoints returns all stored endpoint definitions
+	Endpoints() ([]*models.Endpoint, error)
+	// RenameEndpoint moves an endpoint and its check results to a new ID,
+	// updating the incidents, silences and maintenance windows that refer to it
+	RenameEndpoint(oldID, newID string) error
+
+	// AppendResult stores a check result of an endpoint
+	AppendResult(endpointID string, result models.CheckResult) error
+	// Results returns the results of an endpoint within [from, to], oldest
+	// first, keeping only the newest limit entries. Zero times and a zero
+	// limit are unbounded.
+	Results(endpointID string, from, to time.Time, limit int) ([]models.CheckResult, error)
+
+	// SaveIncident creates or replaces an incident
+	SaveIncident(incident *models.Incident) error
+	// Incident returns a stored incident, or nil if it does not exist
+	Incident(id string) (*models.Incident, error)
+	// Incidents returns all stored incidents
+	Incidents() ([]*models.Incident, error)
+
+	// SaveSilence creates or replaces a silence
+	SaveSilence(silence *models.Silence) error
+	// Silences returns all stored silences
+	Silences() ([]*models.Silence, error)
+
+	// SaveMaintenanceWindow creates or replaces a maintenance window
+	SaveMaintenanceWindow(window *models.MaintenanceWindow) error
+	// DeleteMaintenanceWindow removes a maintenance window
+	DeleteMaintenanceWindow(id string) error
+	// MaintenanceWindows returns all stored maintenance windows
+	MaintenanceWindows() ([]*models.MaintenanceWindow, error)
+
+	// Prune drops check results, resolved incidents, expired silences and
+	// one-off maintenance windows that ended before the given time
+	Prune(before time.Time) error
+	// Close releases the underlying database
+	Close() error
+}
+
+// Open creates the store for the configured backend. An empty backend
+// disables persistence and returns a nil store.
+func Open(backend, path string) (Store, error) {
+	switch backend {
+	case "":
+		return nil, nil
+	case "bolt":
+		return NewBoltStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", backend)
+	}
+}
@@ -1,5 +1,10 @@
 let ws;
 let endpoints = new Map();
+let history = new Map();
+let silences = [];
+let incidents = [];
+const incidentLimit = 20;
+const sparklineSize = 30;
 
 // Initialize WebSocket connection
 function initWebSocket() {
@@ -8,10 +13,22 @@ function initWebSocket() {
     
     ws.onopen = function() {
         console.log('WebSocket connected');
+        loadIncidents();
     };
     
     ws.onmessage = function(event) {
-        const endpoint = JSON.parse(event.data);
+        const data = JSON.parse(event.data);
+        if (data.type === 'incident') {
+            recordIncident(data.incident);
+            return;
+        }
+        const endpoint = data;
+        if (endpoint.history) {
+            history.set(endpoint.id, endpoint.history);
+            delete endpoint.history;
+        } else {
+            recordHistory(endpoint);
+        }
         endpoints.set(endpoint.id, endpoint);
         renderEndpoints();
     };
@@ -26,6 +43,42 @@ function initWebSocket() {
     };
 }
 
+// Append an endpoint update to its sparkline history
+function recordHistory(endpoint) {
+    if (!endpoint.lastCheck || endpoint.status === 'checking') {
+        return;
+    }
+    const results = history.get(endpoint.id) || [];
+    const last = results[results.length - 1];
+    if (last && last.timestamp === endpoint.lastCheck) {
+        return;
+    }
+    results.push({
+        timestamp: endpoint.lastCheck,
+        status: endpoint.lastResult || endpoint.status,
+        responseTime: endpoint.responseTime,
+        maintenance: endpoint.status === 'maintenance'
+    });
+    history.set(endpoint.id, results.slice(-sparklineSize));
+}
+
+// Render recent results as bars scaled by response time
+function renderSparkline(id) {
+    const results = history.get(id) || [];
+    if (results.length === 0) {
+        return '';
+    }
+    const max = Math.max(1, ...results.map(r => r.responseTime || 0));
+    let html = '<div class="sparkline">';
+    results.forEach(result => {
+        const height = Math.max(15, Math.round(100 * (result.responseTime || 0) / max));
+        const title = formatTime(result.timestamp) + ': ' + result.status + (result.maintenance ? ' (maintenance)' : '') + ', ' + (result.responseTime || 0) + 'ms';
+        html += '<span class="spark spark-' + (result.maintenance ? 'maintenance' : result.status) + '" style="height:' + height + '%" title="' + title + '"></span>';
+    });
+    html += '</div>';
+    return html;
+}
+
 // Load initial endpoints
 async function loadEndpoints() {
     try {
@@ -55,21 +108,37 @@ function renderEndpoints() {
     endpointsArray.forEach(endpoint => {
         html += '<div class="endpoint-card ' + endpoint.status + '">';
         html += '<div class="endpoint-header">';
-        html += '<div class="endpoint-name">' + endpoint.name + '</div>';
+        html += '<div class="endpoint-name">' + escapeHTML(endpoint.name) + (isSilenced(endpoint) ? ' <span class="silenced-badge" title="Notifications silenced">🔕</span>' : '') + '</div>';
         html += '<div class="status-badge status-' + endpoint.status + '">' + endpoint.status + '</div>';
         html += '</div>';
-        html += '<div class="endpoint-url">' + endpoint.method + ' ' + endpoint.url + '</div>';
+        html += '<div class="endpoint-url">' + escapeHTML(endpoint.method + ' ' + endpoint.url) + '</div>';
         html += '<div class="endpoint-details">';
         html += '<div class="detail-item"><div class="detail-label">Status Code</div><div class="detail-value">' + (endpoint.statusCode || 'N/A') + '</div></div>';
         html += '<div class="detail-item"><div class="detail-label">Response Time</div><div class="detail-value">' + (endpoint.responseTime || 0) + 'ms</div></div>';
         html += '<div class="detail-item"><div class="detail-label">Last Check</div><div class="detail-value">' + formatTime(endpoint.lastCheck) + '</div></div>';
         html += '<div class="detail-item"><div class="detail-label">Interval</div><div class="detail-value">' + endpoint.interval + 's</div></div>';
+        if (endpoint.lastResult && endpoint.lastResult !== endpoint.status) {
+            html += '<div class="detail-item"><div class="detail-label">Last Result</div><div class="detail-value">' + endpoint.lastResult + ' (' + Math.max(endpoint.consecutiveFailures, endpoint.consecutiveSuccesses) + 'x)</div></div>';
+        }
+        if (endpoint.ackedBy) {
+            html += '<div class="detail-item"><div class="detail-label">Acknowledged</div><div class="detail-value">' + escapeHTML(endpoint.ackedBy) + '</div></div>';
+        }
+        if (endpoint.tlsCert) {
+            html += '<div class="detail-item"><div class="detail-label">Cert Expiry</div><div class="detail-value">' + new Date(endpoint.tlsCert.earliestExpiry).toLocaleDateString() + (endpoint.tlsCert.chainValid ? '' : ' (invalid chain)') + '</div></div>';
+        }
         html += '</div>';
+        html += renderSparkline(endpoint.id);
         if (endpoint.error) {
-            html += '<div class="error-message">' + endpoint.error + '</div>';
+            html += '<div class="error-message">' + escapeHTML(endpoint.error) + '</div>';
         }
         html += '<div class="endpoint-actions">';
         html += '<button class="btn btn-success" onclick="checkEndpoint(\'' + endpoint.id + '\')">Check Now</button>';
+        if (endpoint.incidentId && !endpoint.ackedBy) {
+            html += '<button class="btn" onclick="acknowledgeEndpoint(\'' + endpoint.id + '\')">Acknowledge</button>';
+        }
+        if (!isSilenced(endpoint)) {
+            html += '<button class="btn" onclick="silenceEndpoint(\'' + endpoint.id + '\')">Silence</button>';
+        }
         html += '<button class="btn btn-danger" onclick="removeEndpoint(\'' + endpoint.id + '\')">Remove</button>';
         html += '</div>';
         html += '</div>';
@@ -78,6 +147,16 @@ function renderEndpoints() {
     container.innerHTML = html;
 }
 
+// Escape text for use in HTML markup
+function escapeHTML(text) {
+    return String(text)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+}
+
 // Format time for display
 function formatTime(timeString) {
     const date = new Date(timeString);
@@ -121,7 +200,7 @@ document.getElementById('endpointForm').addEventListener('submit', async functio
             e.target.reset();
             loadEndpoints(); // Reload to get the new endpoint
         } else {
-            alert('Error adding endpoint');
+            alert('Error adding endpoint: ' + await response.text());
         }
     } catch (error) {
         console.error('Error adding endpoint:', error);
@@ -153,6 +232,7 @@ async function removeEndpoint(id) {
         
         if (response.ok) {
             endpoints.delete(id);
+            history.delete(id);
             renderEndpoints();
         } else {
             alert('Error removing endpoint');
@@ -163,8 +243,214 @@ async function removeEndpoint(id) {
     }
 }
 
+// Load active silences
+async function loadSilences() {
+    try {
+        const response = await fetch('/api/silences');
+        silences = await response.json();
+        renderSilences();
+        renderEndpoints();
+    } catch (error) {
+        console.error('Error loading silences:', error);
+    }
+}
+
+// Check whether an active silence matches the endpoint
+function isSilenced(endpoint) {
+    const now = new Date();
+    return silences.some(silence => {
+        if (new Date(silence.startsAt) > now || new Date(silence.endsAt) <= now) {
+            return false;
+        }
+        if (silence.endpointId && silence.endpointId !== endpoint.id) {
+            return false;
+        }
+        const labels = endpoint.labels || {};
+        return Object.entries(silence.matchers || {}).every(([name, value]) => labels[name] === value);
+    });
+}
+
+// Render the list of active silences
+function renderSilences() {
+    const container = document.getElementById('silencesContainer');
+    if (silences.length === 0) {
+        container.innerHTML = '<div class="silence-empty">No active silences</div>';
+        return;
+    }
+
+    let html = '';
+    silences.forEach(silence => {
+        let target = Object.entries(silence.matchers || {}).map(([name, value]) => name + '=' + value).join(', ');
+        if (silence.endpointId) {
+            const endpoint = endpoints.get(silence.endpointId);
+            target = (endpoint ? endpoint.name : silence.endpointId) + (target ? ' (' + target + ')' : '');
+        }
+        html += '<div class="silence-item">';
+        html += '<div><strong>' + escapeHTML(target) + '</strong> until ' + new Date(silence.endsAt).toLocaleString();
+        if (silence.createdBy || silence.comment) {
+            html += '<div class="silence-meta">' + escapeHTML([silence.createdBy, silence.comment].filter(Boolean).join(': ')) + '</div>';
+        }
+        html += '</div>';
+        html += '<button class="btn btn-danger" onclick="expireSilence(\'' + silence.id + '\')">Expire</button>';
+        html += '</div>';
+    });
+    container.innerHTML = html;
+}
+
+// Silence notifications for an endpoint
+async function silenceEndpoint(id) {
+    const duration = prompt('Silence notifications for how long? (e.g. 30m, 2h)', '1h');
+    if (!duration) {
+        return;
+    }
+    const comment = prompt('Comment (optional)', '') || '';
+
+    try {
+        const response = await fetch('/api/silences', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ endpointId: id, duration: duration, comment: comment })
+        });
+        if (response.ok) {
+            loadSilences();
+        } else {
+            alert('Error creating silence: ' + await response.text());
+        }
+    } catch (error) {
+        console.error('Error creating silence:', error);
+    }
+}
+
+// Expire a silence
+async function expireSilence(id) {
+    try {
+        await fetch('/api/silences/' + id, {
+            method: 'DELETE'
+        });
+        loadSilences();
+    } catch (error) {
+        console.error('Error expiring silence:', error);
+    }
+}
+
+// Load the most recent incidents
+async function loadIncidents() {
+    try {
+        const response = await fetch('/api/incidents?limit=' + incidentLimit);
+        incidents = await response.json();
+        renderIncidents();
+    } catch (error) {
+        console.error('Error loading incidents:', error);
+    }
+}
+
+// Insert or replace an incident received over the WebSocket
+function recordIncident(incident) {
+    incidents = incidents.filter(existing => existing.id !== incident.id);
+    incidents.push(incident);
+    incidents.sort((a, b) => new Date(b.startedAt) - new Date(a.startedAt));
+    incidents = incidents.slice(0, incidentLimit);
+    renderIncidents();
+}
+
+// Return the state of an incident: open, acknowledged or resolved
+function incidentState(incident) {
+    if (incident.resolvedAt) {
+        return 'resolved';
+    }
+    return incident.ackedAt ? 'acknowledged' : 'open';
+}
+
+// Render the list of recent incidents
+function renderIncidents() {
+    const container = document.getElementById('incidentsContainer');
+    if (incidents.length === 0) {
+        container.innerHTML = '<div class="silence-empty">No incidents</div>';
+        return;
+    }
+
+    let html = '';
+    incidents.forEach(incident => {
+        const state = incidentState(incident);
+        const end = incident.resolvedAt ? new Date(incident.resolvedAt) : new Date();
+        const minutes = Math.max(1, Math.round((end - new Date(incident.startedAt)) / 60000));
+
+        html += '<div class="incident-item">';
+        html += '<div><strong>' + escapeHTML(incident.endpointName) + '</strong> <span class="incident-state ' + state + '">' + state + '</span>';
+        html += '<div class="incident-meta">' + new Date(incident.startedAt).toLocaleString() + ', ' + minutes + ' min, ' + incident.checkCount + ' failed checks';
+        if (incident.ackedBy) {
+            html += ', acknowledged by ' + escapeHTML(incident.ackedBy);
+        }
+        html += '</div>';
+        if (incident.lastError) {
+            html += '<div class="incident-meta">' + escapeHTML(incident.lastError) + '</div>';
+        }
+        (incident.notes || []).forEach(note => {
+            html += '<div class="incident-meta">📝 ' + escapeHTML([note.author, note.text].filter(Boolean).join(': ')) + '</div>';
+        });
+        html += '</div>';
+        html += '<button class="btn" onclick="addIncidentNote(\'' + incident.id + '\')">Add Note</button>';
+        html += '</div>';
+    });
+    container.innerHTML = html;
+}
+
+// Add a note to an incident
+async function addIncidentNote(id) {
+    const text = prompt('Note', '');
+    if (!text) {
+        return;
+    }
+    const author = prompt('Author', '');
+    if (author === null) {
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/incidents/' + id + '/notes', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ author: author, text: text })
+        });
+        if (!response.ok) {
+            alert('Error adding note: ' + await response.text());
+        }
+    } catch (error) {
+        console.error('Error adding note:', error);
+    }
+}
+
+// Acknowledge the open incident of an endpoint
+async function acknowledgeEndpoint(id) {
+    const by = prompt('Acknowledge as', '');
+    if (by === null) {
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/endpoints/' + id + '/ack', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ by: by })
+        });
+        if (!response.ok) {
+            alert('Error acknowledging incident: ' + await response.text());
+        }
+    } catch (error) {
+        console.error('Error acknowledging incident:', error);
+    }
+}
+
 // Initialize the application
 document.addEventListener('DOMContentLoaded', function() {
     initWebSocket();
     loadEndpoints();
+    loadSilences();
+    setInterval(loadSilences, 30000);
 });